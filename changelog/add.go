@@ -0,0 +1,123 @@
+package changelog
+
+import (
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+// AddEntry adds text as a bullet at the end of category in the Unreleased
+// section. A missing category is created at its position in the canonical
+// order. Every other line is left untouched.
+func (d Document) AddEntry(category Category, text string) ([]byte, error) {
+	if !IsCanonicalCategory(string(category)) {
+		return nil, microerror.Maskf(invalidConfigError, "category must be one of Added, Changed, Deprecated, Removed, Fixed, Security, got %#q", category)
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, microerror.Maskf(invalidConfigError, "entry must not be empty")
+	}
+	if strings.Contains(text, "\n") {
+		return nil, microerror.Maskf(invalidConfigError, "entry must be a single line")
+	}
+	bullet := "- " + text
+
+	content, err := d.addLines(UnreleasedVersion, category, []string{bullet})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return content, nil
+}
+
+// addLines adds lines at the end of category in the section of version. A
+// missing category is created at its position in the canonical order.
+func (d Document) addLines(version string, category Category, lines []string) ([]byte, error) {
+	s, ok := d.Section(version)
+	if !ok {
+		return nil, microerror.Maskf(invalidChangelogError, "changelog has no %#q section", "## ["+version+"]")
+	}
+
+	type heading struct {
+		name string
+		line int
+	}
+	var headings []heading
+	for i := s.BodyStart; i < s.BodyEnd; i++ {
+		if name, ok := CategoryOf(d.Lines[i]); ok {
+			headings = append(headings, heading{name: name, line: i})
+		}
+	}
+
+	// Append to the existing category.
+	for k, h := range headings {
+		if h.name != string(category) {
+			continue
+		}
+
+		end := s.BodyEnd
+		if k+1 < len(headings) {
+			end = headings[k+1].line
+		}
+		last := d.lastContentLine(h.line, end)
+		if last == h.line {
+			return d.insertLines(h.line+1, append([]string{""}, lines...)...), nil
+		}
+		return d.insertLines(last+1, lines...), nil
+	}
+
+	// Create the category before the first one following it in canonical
+	// order.
+	created := append([]string{"### " + string(category), ""}, lines...)
+	for _, h := range headings {
+		if IsCanonicalCategory(h.name) && categoryIndex(Category(h.name)) > categoryIndex(category) {
+			return d.insertLines(h.line, append(created, "")...), nil
+		}
+	}
+
+	// Or at the end of the section.
+	last := d.lastContentLine(s.HeaderLine, s.BodyEnd)
+
+	return d.insertLines(last+1, append([]string{""}, created...)...), nil
+}
+
+// lastContentLine returns the last line in (start, end) which isn't blank or
+// a link definition, or start if there is none.
+func (d Document) lastContentLine(start, end int) int {
+	last := start
+	for i := start + 1; i < end; i++ {
+		if strings.TrimSpace(d.Lines[i]) != "" && !linkRefLineRegex.MatchString(d.Lines[i]) {
+			last = i
+		}
+	}
+	return last
+}
+
+// insertLines returns the document with lines inserted before line at. Blank
+// lines are added around them where needed to separate them from adjacent
+// content.
+func (d Document) insertLines(at int, lines ...string) []byte {
+	if at > 0 && strings.TrimSpace(d.Lines[at-1]) != "" && lines[0] != "" && !strings.HasPrefix(lines[0], "- ") {
+		lines = append([]string{""}, lines...)
+	}
+	if at < len(d.Lines) && strings.TrimSpace(d.Lines[at]) != "" && lines[len(lines)-1] != "" {
+		lines = append(lines, "")
+	}
+
+	out := make([]string, 0, len(d.Lines)+len(lines))
+	out = append(out, d.Lines[:at]...)
+	out = append(out, lines...)
+	out = append(out, d.Lines[at:]...)
+
+	return []byte(strings.Join(out, "\n"))
+}
+
+func categoryIndex(c Category) int {
+	for i, canonical := range Categories {
+		if canonical == c {
+			return i
+		}
+	}
+	return len(Categories)
+}
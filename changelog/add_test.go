@@ -0,0 +1,95 @@
+package changelog
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDocument_AddEntry(t *testing.T) {
+	testCases := []struct {
+		name            string
+		content         string
+		category        Category
+		text            string
+		expectedContent string
+		errorMatcher    func(err error) bool
+	}{
+		{
+			name:            "case 0: existing category",
+			content:         "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- First.\n\n### Fixed\n\n- Fix.\n\n## [1.0.0] - 2026-10-01\n\n### Added\n\n- Old.\n",
+			category:        CategoryAdded,
+			text:            "Second.",
+			expectedContent: "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- First.\n- Second.\n\n### Fixed\n\n- Fix.\n\n## [1.0.0] - 2026-10-01\n\n### Added\n\n- Old.\n",
+		},
+		{
+			name:            "case 1: existing empty category",
+			content:         "# Changelog\n\n## [Unreleased]\n\n### Fixed\n\n## [1.0.0] - 2026-10-01\n",
+			category:        CategoryFixed,
+			text:            "Fix.",
+			expectedContent: "# Changelog\n\n## [Unreleased]\n\n### Fixed\n\n- Fix.\n\n## [1.0.0] - 2026-10-01\n",
+		},
+		{
+			name:            "case 2: missing category in canonical order",
+			content:         "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Feature.\n\n### Security\n\n- CVE.\n\n## [1.0.0] - 2026-10-01\n",
+			category:        CategoryFixed,
+			text:            "Fix.",
+			expectedContent: "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Feature.\n\n### Fixed\n\n- Fix.\n\n### Security\n\n- CVE.\n\n## [1.0.0] - 2026-10-01\n",
+		},
+		{
+			name:            "case 3: missing category at the end of the last section",
+			content:         "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Feature.\n\n[Unreleased]: https://github.com/giantswarm/my-app/tree/main\n",
+			category:        CategoryRemoved,
+			text:            "Remove flag.",
+			expectedContent: "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Feature.\n\n### Removed\n\n- Remove flag.\n\n[Unreleased]: https://github.com/giantswarm/my-app/tree/main\n",
+		},
+		{
+			name:            "case 4: empty section",
+			content:         "# Changelog\n\n## [Unreleased]\n## [1.0.0] - 2026-10-01\n",
+			category:        CategoryChanged,
+			text:            "  Change.  ",
+			expectedContent: "# Changelog\n\n## [Unreleased]\n\n### Changed\n\n- Change.\n\n## [1.0.0] - 2026-10-01\n",
+		},
+		{
+			name:         "case 5: missing unreleased section",
+			content:      "# Changelog\n\n## [1.0.0] - 2026-10-01\n",
+			category:     CategoryAdded,
+			text:         "Feature.",
+			errorMatcher: IsInvalidChangelog,
+		},
+		{
+			name:         "case 6: non-canonical category",
+			content:      "# Changelog\n\n## [Unreleased]\n",
+			category:     Category("Misc"),
+			text:         "Feature.",
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			content, err := Parse(tc.content).AddEntry(tc.category, tc.text)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tc.expectedContent, string(content)); diff != "" {
+				t.Errorf("content mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+// Package changelog parses CHANGELOG.md files following Keep a Changelog, see
+// https://keepachangelog.com/en/1.1.0/.
+package changelog
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Category is a Keep a Changelog H3 section name.
+type Category string
+
+const (
+	CategoryAdded      Category = "Added"
+	CategoryChanged    Category = "Changed"
+	CategoryDeprecated Category = "Deprecated"
+	CategoryRemoved    Category = "Removed"
+	CategoryFixed      Category = "Fixed"
+	CategorySecurity   Category = "Security"
+)
+
+// FileName is the name of the changelog file.
+const FileName = "CHANGELOG.md"
+
+// UnreleasedVersion is the version key of the section of unreleased changes.
+const UnreleasedVersion = "Unreleased"
+
+// Categories is the canonical order of the categories, per
+// https://keepachangelog.com/en/1.1.0/.
+var Categories = []Category{
+	CategoryAdded, CategoryChanged, CategoryDeprecated,
+	CategoryRemoved, CategoryFixed, CategorySecurity,
+}
+
+var (
+	sectionHeaderRegex = regexp.MustCompile(`^## \[([^\]]+)\]`)
+	linkRefLineRegex   = regexp.MustCompile(`^\[[^\]]+\]:\s*https?://`)
+)
+
+// Section is one "## [...]" block. Its body spans lines [BodyStart, BodyEnd)
+// of the document.
+type Section struct {
+	// Version is the text between the brackets of the header, e.g. `1.2.3`
+	// or `Unreleased`.
+	Version    string
+	HeaderLine int
+	BodyStart  int
+	BodyEnd    int
+}
+
+// Document is a line-indexed CHANGELOG.md. FooterStart is the first line of
+// the trailing block of blank + link-reference lines (or len(Lines) if there
+// is none); sections are only parsed above it, so a section body can never
+// spill into the footer link definitions.
+type Document struct {
+	Lines       []string
+	Sections    []Section
+	FooterStart int
+}
+
+// Entry is a single bullet of a section.
+type Entry struct {
+	Category Category
+	// Text is the bullet without its marker. Continuation lines are joined
+	// with single spaces.
+	Text string
+}
+
+// Parse parses content into a Document.
+func Parse(content string) Document {
+	lines := strings.Split(content, "\n")
+
+	// Isolate the trailing footer: the maximal run of blank / link-reference
+	// lines at the end of the file.
+	footerStart := len(lines)
+	for footerStart > 0 {
+		l := lines[footerStart-1]
+		if strings.TrimSpace(l) == "" || linkRefLineRegex.MatchString(l) {
+			footerStart--
+			continue
+		}
+		break
+	}
+
+	var sections []Section
+	for i := 0; i < footerStart; i++ {
+		match := sectionHeaderRegex.FindStringSubmatch(lines[i])
+		if match == nil {
+			continue
+		}
+		if n := len(sections); n > 0 {
+			sections[n-1].BodyEnd = i
+		}
+		sections = append(sections, Section{
+			Version:    match[1],
+			HeaderLine: i,
+			BodyStart:  i + 1,
+			BodyEnd:    footerStart,
+		})
+	}
+
+	return Document{Lines: lines, Sections: sections, FooterStart: footerStart}
+}
+
+// Section returns the section of the given version.
+func (d Document) Section(version string) (Section, bool) {
+	for _, s := range d.Sections {
+		if s.Version == version {
+			return s, true
+		}
+	}
+	return Section{}, false
+}
+
+// Body returns a section's content lines with link-reference definitions
+// removed and leading/trailing blank lines trimmed.
+func (d Document) Body(s Section) []string {
+	var out []string
+	for _, line := range d.Lines[s.BodyStart:s.BodyEnd] {
+		if linkRefLineRegex.MatchString(line) {
+			continue
+		}
+		out = append(out, line)
+	}
+	for len(out) > 0 && strings.TrimSpace(out[0]) == "" {
+		out = out[1:]
+	}
+	for len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+// Entries returns the bullets of a section in order. Bullets outside a
+// category heading are returned with an empty category.
+func (d Document) Entries(s Section) []Entry {
+	var entries []Entry
+
+	current := Category("")
+	for _, line := range d.Body(s) {
+		if name, ok := CategoryOf(line); ok {
+			current = Category(name)
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			continue
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			entries = append(entries, Entry{Category: current, Text: strings.TrimSpace(line[2:])})
+		case len(entries) > 0 && entries[len(entries)-1].Category == current:
+			// Continuation of the previous bullet, including nested
+			// bullets.
+			entries[len(entries)-1].Text += " " + trimmed
+		default:
+			entries = append(entries, Entry{Category: current, Text: trimmed})
+		}
+	}
+
+	return entries
+}
+
+// SpliceBody replaces the body of section s with merged (a block ending in a
+// newline), leaving every other line - preamble, other sections and the
+// footer - untouched.
+func (d Document) SpliceBody(s Section, merged string) []byte {
+	out := make([]string, 0, len(d.Lines))
+	out = append(out, d.Lines[:s.BodyStart]...) // up to and including the header
+	out = append(out, "")                       // blank line after the header
+	out = append(out, strings.Split(strings.TrimRight(merged, "\n"), "\n")...)
+
+	tail := d.Lines[s.BodyEnd:]
+	// Separate the merged body from the following content with one blank line,
+	// unless the tail already starts blank.
+	if len(tail) > 0 && strings.TrimSpace(tail[0]) != "" {
+		out = append(out, "")
+	}
+	out = append(out, tail...)
+
+	return []byte(strings.Join(out, "\n"))
+}
+
+// CategoryOf reports whether line is a "### <name>" heading and returns the
+// trimmed name.
+func CategoryOf(line string) (string, bool) {
+	if !strings.HasPrefix(line, "### ") {
+		return "", false
+	}
+	return strings.TrimRightFunc(line[len("### "):], unicode.IsSpace), true
+}
+
+// IsCanonicalCategory reports whether name is one of Categories.
+func IsCanonicalCategory(name string) bool {
+	for _, c := range Categories {
+		if string(c) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package changelog
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDocument_Entries(t *testing.T) {
+	testCases := []struct {
+		name            string
+		content         string
+		version         string
+		expectedFound   bool
+		expectedEntries []Entry
+	}{
+		{
+			name: "case 0: categorized entries with continuation lines",
+			content: `# Changelog
+
+## [Unreleased]
+
+### Added
+
+- First.
+- Second,
+  continued.
+  - Nested.
+
+### Fixed
+
+* Third.
+
+## [1.0.0] - 2026-01-01
+
+### Added
+
+- Old.
+
+[Unreleased]: https://github.com/giantswarm/my-app/compare/v1.0.0...HEAD
+`,
+			version:       "Unreleased",
+			expectedFound: true,
+			expectedEntries: []Entry{
+				{Category: CategoryAdded, Text: "First."},
+				{Category: CategoryAdded, Text: "Second, continued. - Nested."},
+				{Category: CategoryFixed, Text: "Third."},
+			},
+		},
+		{
+			name:          "case 1: entries before any category",
+			content:       "## [1.0.0]\n\nFree text.\n- Bullet.\n",
+			version:       "1.0.0",
+			expectedFound: true,
+			expectedEntries: []Entry{
+				{Text: "Free text."},
+				{Text: "Bullet."},
+			},
+		},
+		{
+			name:    "case 2: missing section",
+			content: "## [1.0.0]\n\n### Added\n\n- Bullet.\n",
+			version: "2.0.0",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			doc := Parse(tc.content)
+			section, found := doc.Section(tc.version)
+			if found != tc.expectedFound {
+				t.Fatalf("found == %t, want %t", found, tc.expectedFound)
+			}
+			if !found {
+				return
+			}
+
+			if diff := cmp.Diff(tc.expectedEntries, doc.Entries(section)); diff != "" {
+				t.Errorf("entries mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
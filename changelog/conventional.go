@@ -0,0 +1,162 @@
+package changelog
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/giantswarm/microerror"
+)
+
+var (
+	// conventionalHeaderRegex matches the header of a conventional commit
+	// message such as `feat(api)!: add endpoint`, see
+	// https://www.conventionalcommits.org/en/v1.0.0/.
+	conventionalHeaderRegex = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^)]*)\))?(!)?: +(\S.*)$`)
+	breakingFooterRegex     = regexp.MustCompile(`^BREAKING[ -]CHANGE: *(.*)$`)
+	// pullRequestSuffixRegex matches the plain text of a pull request
+	// reference at the end of an entry, e.g. `(#12)` or
+	// `(#12 (https://github.com/...))`.
+	pullRequestSuffixRegex = regexp.MustCompile(`\s*\(#[0-9]+(?:\s*\([^)]*\))?\)$`)
+)
+
+// conventionalCategories maps conventional commit types to the category of
+// their entries. Commits of other types, e.g. `chore` or `docs`, don't make
+// entries unless they are breaking changes.
+var conventionalCategories = map[string]Category{
+	"feat":   CategoryAdded,
+	"fix":    CategoryFixed,
+	"perf":   CategoryChanged,
+	"revert": CategoryRemoved,
+}
+
+// ConventionalCommit is a commit message following Conventional Commits.
+type ConventionalCommit struct {
+	Type        string
+	Scope       string
+	Description string
+	Breaking    bool
+	// BreakingNote is the text of the `BREAKING CHANGE:` footer, if any.
+	BreakingNote string
+}
+
+// ParseConventionalCommit parses message and reports whether it follows
+// Conventional Commits.
+func ParseConventionalCommit(message string) (ConventionalCommit, bool) {
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+
+	match := conventionalHeaderRegex.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if match == nil {
+		return ConventionalCommit{}, false
+	}
+
+	c := ConventionalCommit{
+		Type:        strings.ToLower(match[1]),
+		Scope:       strings.TrimSpace(match[2]),
+		Description: strings.TrimSpace(match[4]),
+		Breaking:    match[3] != "",
+	}
+	for _, line := range lines[1:] {
+		if m := breakingFooterRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			c.Breaking = true
+			c.BreakingNote = strings.TrimSpace(m[1])
+			break
+		}
+	}
+
+	return c, true
+}
+
+// Entry returns the changelog entry of c, and false if c doesn't make one.
+// Breaking changes are Changed entries, described by their
+// `BREAKING CHANGE:` footer if there is one.
+func (c ConventionalCommit) Entry() (Entry, bool) {
+	category, ok := conventionalCategories[c.Type]
+	text := c.Description
+	if c.Breaking && c.BreakingNote != "" {
+		text = c.BreakingNote
+	}
+	if r, size := utf8.DecodeRuneInString(text); r != utf8.RuneError {
+		text = string(unicode.ToUpper(r)) + text[size:]
+	}
+	if c.Breaking {
+		category, ok = CategoryChanged, true
+		text = "**Breaking:** " + text
+	}
+	if !ok {
+		return Entry{}, false
+	}
+
+	if c.Scope != "" {
+		text = "`" + c.Scope + "`: " + text
+	}
+	if !strings.HasSuffix(text, ".") && !strings.HasSuffix(text, ")") {
+		text += "."
+	}
+
+	return Entry{Category: category, Text: text}, true
+}
+
+// ConventionalEntries returns the entries of the conventional commits
+// among commits, in order.
+func ConventionalEntries(commits []Commit) []Entry {
+	var entries []Entry
+	for _, c := range commits {
+		cc, ok := ParseConventionalCommit(c.Message)
+		if !ok {
+			continue
+		}
+		if e, ok := cc.Entry(); ok {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// MergeEntries adds entries to the section of version, unless the section
+// already has an entry with the same text. Texts are compared as plain text,
+// ignoring case, a trailing pull request reference and a trailing period.
+// It returns the new content and the entries which have been added.
+func (d Document) MergeEntries(version string, entries []Entry) ([]byte, []Entry, error) {
+	s, ok := d.Section(version)
+	if !ok {
+		return nil, nil, microerror.Maskf(invalidChangelogError, "changelog has no %#q section", "## ["+version+"]")
+	}
+
+	seen := map[string]bool{}
+	for _, e := range d.Entries(s) {
+		seen[entryKey(e.Text)] = true
+	}
+
+	content := []byte(strings.Join(d.Lines, "\n"))
+
+	var added []Entry
+	for _, e := range entries {
+		if !IsCanonicalCategory(string(e.Category)) {
+			return nil, nil, microerror.Maskf(invalidConfigError, "category must be one of Added, Changed, Deprecated, Removed, Fixed, Security, got %#q", e.Category)
+		}
+		key := entryKey(e.Text)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		var err error
+		content, err = Parse(string(content)).addLines(version, e.Category, []string{"- " + strings.TrimSpace(e.Text)})
+		if err != nil {
+			return nil, nil, microerror.Mask(err)
+		}
+		added = append(added, e)
+	}
+
+	return content, added, nil
+}
+
+// entryKey returns the text entries are compared by to find duplicates.
+func entryKey(text string) string {
+	text = strings.TrimSuffix(strings.TrimSpace(PlainText(text)), ".")
+	text = pullRequestSuffixRegex.ReplaceAllString(text, "")
+	text = strings.TrimSuffix(text, ".")
+	return strings.ToLower(text)
+}
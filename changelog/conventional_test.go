@@ -0,0 +1,155 @@
+package changelog
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConventionalCommit_Entry(t *testing.T) {
+	testCases := []struct {
+		name          string
+		message       string
+		expectedEntry Entry
+		expectedOK    bool
+	}{
+		{
+			name:          "case 0: feature",
+			message:       "feat: add endpoint (#12)\n\nLonger description.\n",
+			expectedEntry: Entry{Category: CategoryAdded, Text: "Add endpoint (#12)"},
+			expectedOK:    true,
+		},
+		{
+			name:          "case 1: scoped fix",
+			message:       "fix(api): handle empty body",
+			expectedEntry: Entry{Category: CategoryFixed, Text: "`api`: Handle empty body."},
+			expectedOK:    true,
+		},
+		{
+			name:          "case 2: performance",
+			message:       "perf: cache responses",
+			expectedEntry: Entry{Category: CategoryChanged, Text: "Cache responses."},
+			expectedOK:    true,
+		},
+		{
+			name:          "case 3: revert",
+			message:       "revert: drop legacy flag",
+			expectedEntry: Entry{Category: CategoryRemoved, Text: "Drop legacy flag."},
+			expectedOK:    true,
+		},
+		{
+			name:          "case 4: breaking marker",
+			message:       "feat!: rename flag",
+			expectedEntry: Entry{Category: CategoryChanged, Text: "**Breaking:** Rename flag."},
+			expectedOK:    true,
+		},
+		{
+			name:          "case 5: breaking footer",
+			message:       "refactor: restructure config\n\nBREAKING CHANGE: `--foo` is now `--bar`.\n",
+			expectedEntry: Entry{Category: CategoryChanged, Text: "**Breaking:** `--foo` is now `--bar`."},
+			expectedOK:    true,
+		},
+		{
+			name:    "case 6: chore",
+			message: "chore: bump dependencies",
+		},
+		{
+			name:    "case 7: not conventional",
+			message: "Merge pull request #12 from giantswarm/branch",
+		},
+		{
+			name:          "case 8: multibyte first letter",
+			message:       "feat: élan for the dashboard",
+			expectedEntry: Entry{Category: CategoryAdded, Text: "Élan for the dashboard."},
+			expectedOK:    true,
+		},
+		{
+			name:          "case 9: non-letter first character",
+			message:       "fix: 3rd party charts load again",
+			expectedEntry: Entry{Category: CategoryFixed, Text: "3rd party charts load again."},
+			expectedOK:    true,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			var entry Entry
+			c, ok := ParseConventionalCommit(tc.message)
+			if ok {
+				entry, ok = c.Entry()
+			}
+
+			if ok != tc.expectedOK {
+				t.Fatalf("ok == %v, want %v", ok, tc.expectedOK)
+			}
+			if diff := cmp.Diff(tc.expectedEntry, entry); diff != "" {
+				t.Errorf("entry mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDocument_MergeEntries(t *testing.T) {
+	testCases := []struct {
+		name            string
+		content         string
+		entries         []Entry
+		expectedContent string
+		expectedAdded   []Entry
+		errorMatcher    func(err error) bool
+	}{
+		{
+			name:    "case 0: new and duplicate entries",
+			content: "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Add endpoint ([#12](https://github.com/giantswarm/x/pull/12)).\n\n## [1.0.0] - 2026-10-01\n\n### Fixed\n\n- Handle empty body.\n",
+			entries: []Entry{
+				{Category: CategoryAdded, Text: "Add endpoint (#12)"},
+				{Category: CategoryFixed, Text: "Handle empty body."},
+				{Category: CategoryFixed, Text: "Handle empty body."},
+				{Category: CategoryChanged, Text: "Cache responses."},
+			},
+			expectedContent: "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Add endpoint ([#12](https://github.com/giantswarm/x/pull/12)).\n\n### Changed\n\n- Cache responses.\n\n### Fixed\n\n- Handle empty body.\n\n## [1.0.0] - 2026-10-01\n\n### Fixed\n\n- Handle empty body.\n",
+			expectedAdded: []Entry{
+				{Category: CategoryFixed, Text: "Handle empty body."},
+				{Category: CategoryChanged, Text: "Cache responses."},
+			},
+		},
+		{
+			name:         "case 1: missing section",
+			content:      "# Changelog\n\n## [1.0.0] - 2026-10-01\n",
+			entries:      []Entry{{Category: CategoryAdded, Text: "Add endpoint."}},
+			errorMatcher: IsInvalidChangelog,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			content, added, err := Parse(tc.content).MergeEntries(UnreleasedVersion, tc.entries)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tc.expectedContent, string(content)); diff != "" {
+				t.Errorf("content mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedAdded, added); diff != "" {
+				t.Errorf("added mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package changelog
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var invalidChangelogError = &microerror.Error{
+	Kind: "invalidChangelogError",
+}
+
+// IsInvalidChangelog asserts invalidChangelogError.
+func IsInvalidChangelog(err error) bool {
+	return microerror.Cause(err) == invalidChangelogError
+}
+
+var invalidFragmentError = &microerror.Error{
+	Kind: "invalidFragmentError",
+}
+
+// IsInvalidFragment asserts invalidFragmentError.
+func IsInvalidFragment(err error) bool {
+	return microerror.Cause(err) == invalidFragmentError
+}
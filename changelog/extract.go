@@ -0,0 +1,99 @@
+package changelog
+
+import (
+	"regexp"
+)
+
+var (
+	markdownLinkRegex     = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]+)\)`)
+	markdownEmphasisRegex = regexp.MustCompile("\\*\\*|__|`")
+)
+
+// Link returns the URL of the footer link definition of version, e.g. the
+// compare link of a release.
+func (d Document) Link(version string) (string, bool) {
+	for _, line := range d.Lines[d.FooterStart:] {
+		match := linkDefinitionRegex.FindStringSubmatch(line)
+		if match != nil && match[1] == version {
+			return match[2], true
+		}
+	}
+	return "", false
+}
+
+// Date returns the release date of s, or an empty string if its header has
+// none, e.g. for Unreleased.
+func (d Document) Date(s Section) string {
+	match := releaseHeaderRegex.FindStringSubmatch(d.Lines[s.HeaderLine])
+	if match == nil {
+		return ""
+	}
+	return match[2]
+}
+
+// MarkdownLink is an inline markdown link such as
+// `[#123](https://github.com/giantswarm/app/pull/123)`.
+type MarkdownLink struct {
+	Text string
+	URL  string
+}
+
+// Links returns the inline links of the markdown text, in order.
+func Links(text string) []MarkdownLink {
+	var links []MarkdownLink
+	for _, match := range markdownLinkRegex.FindAllStringSubmatch(text, -1) {
+		links = append(links, MarkdownLink{Text: match[1], URL: match[2]})
+	}
+	return links
+}
+
+// StripLinks returns markdown text with inline links replaced by their text,
+// or by their URL if they have no text.
+func StripLinks(text string) string {
+	return replaceLinks(text, func(l MarkdownLink) string {
+		if l.Text == "" {
+			return l.URL
+		}
+		return l.Text
+	})
+}
+
+// PlainText returns markdown text without inline markup: links are written
+// as `text (url)` and code spans and strong emphasis lose their markers.
+func PlainText(text string) string {
+	text = replaceLinks(text, func(l MarkdownLink) string {
+		if l.Text == "" || l.Text == l.URL {
+			return l.URL
+		}
+		return l.Text + " (" + l.URL + ")"
+	})
+	return markdownEmphasisRegex.ReplaceAllString(text, "")
+}
+
+func replaceLinks(text string, replace func(l MarkdownLink) string) string {
+	return markdownLinkRegex.ReplaceAllStringFunc(text, func(link string) string {
+		match := markdownLinkRegex.FindStringSubmatch(link)
+		return replace(MarkdownLink{Text: match[1], URL: match[2]})
+	})
+}
+
+// trimVersion returns version without the `v` prefix of git tags, e.g.
+// `1.2.3` for `v1.2.3`.
+func trimVersion(version string) string {
+	if len(version) > 1 && version[0] == 'v' && version[1] >= '0' && version[1] <= '9' {
+		return version[1:]
+	}
+	return version
+}
+
+// SectionOf returns the section of version, which may be given as a git
+// tag such as `v1.2.3`, or the Unreleased section if version is empty.
+func (d Document) SectionOf(version string) (Section, bool) {
+	if version == "" {
+		version = UnreleasedVersion
+	}
+	if s, ok := d.Section(version); ok {
+		return s, true
+	}
+	return d.Section(trimVersion(version))
+}
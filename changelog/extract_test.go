@@ -0,0 +1,170 @@
+package changelog
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPlainText(t *testing.T) {
+	testCases := []struct {
+		name         string
+		text         string
+		expectedText string
+	}{
+		{
+			name:         "case 0: plain",
+			text:         "Fix a bug.",
+			expectedText: "Fix a bug.",
+		},
+		{
+			name:         "case 1: links, code spans and emphasis",
+			text:         "Add **`helm images`** ([#12](https://github.com/giantswarm/x/pull/12)).",
+			expectedText: "Add helm images (#12 (https://github.com/giantswarm/x/pull/12)).",
+		},
+		{
+			name:         "case 2: link without text",
+			text:         "See [](https://example.com) and [https://example.com](https://example.com).",
+			expectedText: "See https://example.com and https://example.com.",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			text := PlainText(tc.text)
+			if text != tc.expectedText {
+				t.Fatalf("text == %q, want %q", text, tc.expectedText)
+			}
+		})
+	}
+}
+
+func TestLinks(t *testing.T) {
+	testCases := []struct {
+		name          string
+		text          string
+		expectedLinks []MarkdownLink
+		expectedText  string
+	}{
+		{
+			name:         "case 0: no links",
+			text:         "Fix a bug.",
+			expectedText: "Fix a bug.",
+		},
+		{
+			name: "case 1: absolute and relative links",
+			text: "Add [docs](./docs/README.md) ([#12](https://github.com/giantswarm/x/pull/12)).",
+			expectedLinks: []MarkdownLink{
+				{Text: "docs", URL: "./docs/README.md"},
+				{Text: "#12", URL: "https://github.com/giantswarm/x/pull/12"},
+			},
+			expectedText: "Add docs (#12).",
+		},
+		{
+			name: "case 2: link without text",
+			text: "See [](https://example.com).",
+			expectedLinks: []MarkdownLink{
+				{URL: "https://example.com"},
+			},
+			expectedText: "See https://example.com.",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			links := Links(tc.text)
+			if diff := cmp.Diff(tc.expectedLinks, links); diff != "" {
+				t.Errorf("links mismatch (-want +got):\n%s", diff)
+			}
+
+			text := StripLinks(tc.text)
+			if text != tc.expectedText {
+				t.Fatalf("text == %q, want %q", text, tc.expectedText)
+			}
+		})
+	}
+}
+
+func TestDocument_SectionOf(t *testing.T) {
+	content := `# Changelog
+
+## [Unreleased]
+
+## [1.2.3] - 2026-10-18
+
+### Fixed
+
+- Fix.
+
+[Unreleased]: https://github.com/giantswarm/x/compare/v1.2.3...HEAD
+[1.2.3]: https://github.com/giantswarm/x/releases/tag/v1.2.3
+`
+
+	testCases := []struct {
+		name            string
+		version         string
+		expectedFound   bool
+		expectedVersion string
+		expectedDate    string
+		expectedLink    string
+	}{
+		{
+			name:            "case 0: unreleased by default",
+			version:         "",
+			expectedFound:   true,
+			expectedVersion: "Unreleased",
+			expectedLink:    "https://github.com/giantswarm/x/compare/v1.2.3...HEAD",
+		},
+		{
+			name:            "case 1: version",
+			version:         "1.2.3",
+			expectedFound:   true,
+			expectedVersion: "1.2.3",
+			expectedDate:    "2026-10-18",
+			expectedLink:    "https://github.com/giantswarm/x/releases/tag/v1.2.3",
+		},
+		{
+			name:            "case 2: git tag",
+			version:         "v1.2.3",
+			expectedFound:   true,
+			expectedVersion: "1.2.3",
+			expectedDate:    "2026-10-18",
+			expectedLink:    "https://github.com/giantswarm/x/releases/tag/v1.2.3",
+		},
+		{
+			name:    "case 3: missing version",
+			version: "1.0.0",
+		},
+	}
+
+	doc := Parse(content)
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			s, ok := doc.SectionOf(tc.version)
+			if ok != tc.expectedFound {
+				t.Fatalf("found == %v, want %v", ok, tc.expectedFound)
+			}
+			if !ok {
+				return
+			}
+
+			if s.Version != tc.expectedVersion {
+				t.Fatalf("version == %q, want %q", s.Version, tc.expectedVersion)
+			}
+			if date := doc.Date(s); date != tc.expectedDate {
+				t.Fatalf("date == %q, want %q", date, tc.expectedDate)
+			}
+			if link, _ := doc.Link(s.Version); link != tc.expectedLink {
+				t.Fatalf("link == %q, want %q", link, tc.expectedLink)
+			}
+		})
+	}
+}
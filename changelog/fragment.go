@@ -0,0 +1,165 @@
+package changelog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+)
+
+// FragmentsDir is the directory, relative to the repository root, holding
+// the fragments of unreleased changes.
+const FragmentsDir = ".changelog/unreleased"
+
+// fragmentNameRegex matches fragment file names such as `123-fixed.md` or
+// `my-branch-added.md`.
+var fragmentNameRegex = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)-([A-Za-z]+)\.md$`)
+
+// Fragment is an unreleased changelog entry kept in its own file, named
+// `<id>-<category>.md`, so that parallel changes don't conflict on
+// CHANGELOG.md. Fragments are compiled into the changelog at release time.
+type Fragment struct {
+	// Name is the file name of the fragment.
+	Name     string
+	ID       string
+	Category Category
+	// Lines are the bullets of the fragment, ready to be inserted into a
+	// section.
+	Lines []string
+}
+
+// ParseFragment parses the fragment with file name name. Its content is
+// either a markdown list, one bullet per entry, or a paragraph which makes a
+// single entry. Problems are returned as diagnostics, in line order, and the
+// fragment is only usable if there are none.
+func ParseFragment(name, content string) (Fragment, []Diagnostic) {
+	var diagnostics []Diagnostic
+	report := func(line int, format string, args ...interface{}) {
+		diagnostics = append(diagnostics, Diagnostic{Line: line + 1, Message: fmt.Sprintf(format, args...)})
+	}
+
+	f := Fragment{
+		Name: name,
+	}
+
+	match := fragmentNameRegex.FindStringSubmatch(name)
+	if match == nil {
+		report(0, "fragment file name %#q must be `<id>-<category>.md`", name)
+	} else {
+		f.ID = match[1]
+		for _, c := range Categories {
+			if strings.EqualFold(string(c), match[2]) {
+				f.Category = c
+			}
+		}
+		if f.Category == "" {
+			report(0, "fragment category %#q must be one of added, changed, deprecated, removed, fixed, security", match[2])
+		}
+	}
+
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "* "):
+			line = "- " + strings.TrimPrefix(line, "* ")
+		case strings.HasPrefix(line, "#"):
+			report(i, "headings are not allowed in fragments, the category is taken from the file name")
+			continue
+		case len(f.Lines) == 0 && !strings.HasPrefix(line, "- "):
+			// A paragraph is a single entry.
+			line = "- " + strings.TrimSpace(line)
+		case !strings.HasPrefix(line, "- ") && !strings.HasPrefix(line, " "):
+			// Continuation of the entry.
+			line = "  " + line
+		}
+		f.Lines = append(f.Lines, line)
+	}
+	if len(f.Lines) == 0 && len(diagnostics) == 0 {
+		report(0, "fragment must not be empty")
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool { return diagnostics[i].Line < diagnostics[j].Line })
+
+	return f, diagnostics
+}
+
+// IsFragmentName reports whether name is named like a fragment,
+// `<id>-<category>.md`. Other files, e.g. a README.md documenting the
+// directory, aren't fragments. The category isn't checked, so that a
+// misspelled one is reported instead of being ignored.
+func IsFragmentName(name string) bool {
+	return fragmentNameRegex.MatchString(name)
+}
+
+// ReadFragments reads the fragments in dir, ordered by ID. IDs are compared
+// as numbers when both are, e.g. pull request numbers. Files which aren't
+// named like fragments are ignored, see IsFragmentName. A missing dir has no
+// fragments.
+func ReadFragments(fs afero.Fs, dir string) ([]Fragment, error) {
+	entries, err := afero.ReadDir(fs, dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var fragments []Fragment
+	for _, e := range entries {
+		if e.IsDir() || !IsFragmentName(e.Name()) {
+			continue
+		}
+
+		content, err := afero.ReadFile(fs, filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		f, diagnostics := ParseFragment(e.Name(), string(content))
+		if len(diagnostics) > 0 {
+			return nil, microerror.Maskf(invalidFragmentError, "%s:%s", filepath.Join(dir, e.Name()), diagnostics[0])
+		}
+		fragments = append(fragments, f)
+	}
+
+	sort.SliceStable(fragments, func(i, j int) bool {
+		return lessID(fragments[i].ID, fragments[j].ID)
+	})
+
+	return fragments, nil
+}
+
+// AddFragments adds the entries of fragments to the section of version,
+// creating missing categories in canonical order.
+func (d Document) AddFragments(version string, fragments []Fragment) ([]byte, error) {
+	content := []byte(strings.Join(d.Lines, "\n"))
+	for _, f := range fragments {
+		if !IsCanonicalCategory(string(f.Category)) || len(f.Lines) == 0 {
+			return nil, microerror.Maskf(invalidFragmentError, "fragment %#q is invalid", f.Name)
+		}
+
+		var err error
+		content, err = Parse(string(content)).addLines(version, f.Category, f.Lines)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	return content, nil
+}
+
+func lessID(a, b string) bool {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	return a < b
+}
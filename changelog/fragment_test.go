@@ -0,0 +1,173 @@
+package changelog
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+func TestParseFragment(t *testing.T) {
+	testCases := []struct {
+		name                string
+		fileName            string
+		content             string
+		expectedFragment    Fragment
+		expectedDiagnostics []Diagnostic
+	}{
+		{
+			name:     "case 0: list",
+			fileName: "123-fixed.md",
+			content:  "- Fix a.\n* Fix b,\n  on two lines.\n",
+			expectedFragment: Fragment{
+				Name:     "123-fixed.md",
+				ID:       "123",
+				Category: CategoryFixed,
+				Lines:    []string{"- Fix a.", "- Fix b,", "  on two lines."},
+			},
+		},
+		{
+			name:     "case 1: paragraph",
+			fileName: "my-branch-Added.md",
+			content:  "\nAdd a,\nand b.\n\n",
+			expectedFragment: Fragment{
+				Name:     "my-branch-Added.md",
+				ID:       "my-branch",
+				Category: CategoryAdded,
+				Lines:    []string{"- Add a,", "  and b."},
+			},
+		},
+		{
+			name:     "case 2: invalid name, category and heading",
+			fileName: "fixed.md",
+			content:  "### Fixed\n\n- Fix.\n",
+			expectedDiagnostics: []Diagnostic{
+				{Line: 1, Message: "fragment file name `fixed.md` must be `<id>-<category>.md`"},
+				{Line: 1, Message: "headings are not allowed in fragments, the category is taken from the file name"},
+			},
+		},
+		{
+			name:     "case 3: unknown category",
+			fileName: "1-misc.md",
+			content:  "- Misc.\n",
+			expectedDiagnostics: []Diagnostic{
+				{Line: 1, Message: "fragment category `misc` must be one of added, changed, deprecated, removed, fixed, security"},
+			},
+		},
+		{
+			name:     "case 4: empty",
+			fileName: "1-added.md",
+			content:  "\n\n",
+			expectedDiagnostics: []Diagnostic{
+				{Line: 1, Message: "fragment must not be empty"},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			f, diagnostics := ParseFragment(tc.fileName, tc.content)
+
+			if diff := cmp.Diff(tc.expectedDiagnostics, diagnostics); diff != "" {
+				t.Fatalf("diagnostics mismatch (-want +got):\n%s", diff)
+			}
+			if len(diagnostics) > 0 {
+				return
+			}
+			if diff := cmp.Diff(tc.expectedFragment, f); diff != "" {
+				t.Errorf("fragment mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDocument_AddFragments(t *testing.T) {
+	testCases := []struct {
+		name            string
+		files           map[string]string
+		content         string
+		version         string
+		expectedContent string
+		errorMatcher    func(err error) bool
+	}{
+		{
+			name: "case 0: fragments in canonical and ID order",
+			files: map[string]string{
+				"10-fixed.md":  "- Fix 10.\n",
+				"9-fixed.md":   "- Fix 9.\n",
+				"12-added.md":  "Add 12.\n",
+				"README.txt":   "Not a fragment.\n",
+				"README.md":    "# Changelog fragments\n",
+				"3-removed.md": "- Remove 3.\n- Remove 3 again.\n",
+			},
+			content:         "# Changelog\n\n## [Unreleased]\n\n## [1.1.0] - 2026-10-18\n\n### Added\n\n- Feature.\n\n## [1.0.0] - 2026-10-01\n",
+			version:         "1.1.0",
+			expectedContent: "# Changelog\n\n## [Unreleased]\n\n## [1.1.0] - 2026-10-18\n\n### Added\n\n- Feature.\n- Add 12.\n\n### Removed\n\n- Remove 3.\n- Remove 3 again.\n\n### Fixed\n\n- Fix 9.\n- Fix 10.\n\n## [1.0.0] - 2026-10-01\n",
+		},
+		{
+			name:            "case 1: no fragments",
+			content:         "# Changelog\n\n## [Unreleased]\n",
+			version:         "1.1.0",
+			expectedContent: "# Changelog\n\n## [Unreleased]\n",
+		},
+		{
+			name: "case 2: invalid fragment",
+			files: map[string]string{
+				"1-misc.md": "- Misc.\n",
+			},
+			content:      "# Changelog\n\n## [Unreleased]\n\n## [1.1.0] - 2026-10-18\n",
+			version:      "1.1.0",
+			errorMatcher: IsInvalidFragment,
+		},
+		{
+			name: "case 3: missing section",
+			files: map[string]string{
+				"1-added.md": "- Add.\n",
+			},
+			content:      "# Changelog\n\n## [Unreleased]\n",
+			version:      "1.1.0",
+			errorMatcher: IsInvalidChangelog,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			fs := afero.NewMemMapFs()
+			for name, content := range tc.files {
+				err := afero.WriteFile(fs, FragmentsDir+"/"+name, []byte(content), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			fragments, err := ReadFragments(fs, FragmentsDir)
+			var content []byte
+			if err == nil {
+				content, err = Parse(tc.content).AddFragments(tc.version, fragments)
+			}
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tc.expectedContent, string(content)); diff != "" {
+				t.Errorf("content mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
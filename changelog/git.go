@@ -0,0 +1,132 @@
+package changelog
+
+import (
+	"github.com/giantswarm/gitsemver/v2/pkg/gitsemver"
+	"github.com/giantswarm/microerror"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// Commit is a commit of the git history.
+type Commit struct {
+	Hash    string
+	Message string
+}
+
+// CommitsSinceTag returns the most recent release tag reachable from HEAD of
+// the git repository containing dir, e.g. `v1.2.3` or `v1.2.3-rc.1`, and the
+// commits since, oldest first. Merge commits are left out. If there is no
+// release tag, the tag is empty and the whole history is returned.
+func CommitsSinceTag(dir string) (string, []Commit, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", nil, microerror.Maskf(invalidConfigError, "%#q is not inside a git repository: %s", dir, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", nil, microerror.Mask(err)
+	}
+
+	tags, err := releaseTags(repo)
+	if err != nil {
+		return "", nil, microerror.Mask(err)
+	}
+
+	// Find the most recent tagged commit.
+	var tag string
+	var tagged plumbing.Hash
+	{
+		iter, err := repo.Log(&git.LogOptions{From: head.Hash(), Order: git.LogOrderCommitterTime})
+		if err != nil {
+			return "", nil, microerror.Mask(err)
+		}
+		err = iter.ForEach(func(c *object.Commit) error {
+			if t, ok := tags[c.Hash]; ok {
+				tag, tagged = t, c.Hash
+				return storer.ErrStop
+			}
+			return nil
+		})
+		if err != nil {
+			return "", nil, microerror.Mask(err)
+		}
+	}
+
+	// Commits reachable from the tag are part of its release.
+	released := map[plumbing.Hash]bool{}
+	if tag != "" {
+		iter, err := repo.Log(&git.LogOptions{From: tagged})
+		if err != nil {
+			return "", nil, microerror.Mask(err)
+		}
+		err = iter.ForEach(func(c *object.Commit) error {
+			released[c.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return "", nil, microerror.Mask(err)
+		}
+	}
+
+	var commits []Commit
+	{
+		iter, err := repo.Log(&git.LogOptions{From: head.Hash(), Order: git.LogOrderCommitterTime})
+		if err != nil {
+			return "", nil, microerror.Mask(err)
+		}
+		err = iter.ForEach(func(c *object.Commit) error {
+			if released[c.Hash] || c.NumParents() > 1 {
+				return nil
+			}
+			commits = append(commits, Commit{Hash: c.Hash.String(), Message: c.Message})
+			return nil
+		})
+		if err != nil {
+			return "", nil, microerror.Mask(err)
+		}
+	}
+
+	// Oldest first.
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+
+	return tag, commits, nil
+}
+
+// releaseTags returns the stable and release candidate tags of repo by the
+// commit they point to.
+func releaseTags(repo *git.Repository) (map[plumbing.Hash]string, error) {
+	iter, err := repo.Tags()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	tags := map[plumbing.Hash]string{}
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().Short()
+		if !gitsemver.IsValidStable(name) && !gitsemver.IsValidRC(name) {
+			return nil
+		}
+
+		// Annotated tags point to a tag object.
+		hash := ref.Hash()
+		if t, err := repo.TagObject(hash); err == nil {
+			c, err := t.Commit()
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			hash = c.Hash
+		}
+		tags[hash] = name
+		return nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return tags, nil
+}
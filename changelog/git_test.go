@@ -0,0 +1,85 @@
+package changelog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestCommitsSinceTag tests listing the commits since the most recent
+// release tag of a repository with annotated and non-release tags.
+func TestCommitsSinceTag(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commit := func(i int, message string) {
+		err := os.WriteFile(filepath.Join(dir, "file"), []byte(message), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = wt.Add("file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Unix(int64(i), 0)}
+		_, err = wt.Commit(message, &git.CommitOptions{Author: sig, Committer: sig})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	tag := func(name string, annotated bool) {
+		head, err := repo.Head()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var opts *git.CreateTagOptions
+		if annotated {
+			opts = &git.CreateTagOptions{
+				Message: name,
+				Tagger:  &object.Signature{Name: "test", Email: "test@example.com", When: time.Unix(0, 0)},
+			}
+		}
+		_, err = repo.CreateTag(name, head.Hash(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	commit(1, "feat: add a")
+	tag("v1.0.0", false)
+	commit(2, "fix: fix a")
+	tag("v1.0.1-rc.1", true)
+	commit(3, "chore: bump")
+	commit(4, "feat: add b")
+	tag("latest", false)
+
+	version, commits, err := CommitsSinceTag(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version != "v1.0.1-rc.1" {
+		t.Fatalf("tag == %q, want %q", version, "v1.0.1-rc.1")
+	}
+
+	var messages []string
+	for _, c := range commits {
+		messages = append(messages, c.Message)
+	}
+	if diff := cmp.Diff([]string{"chore: bump", "feat: add b"}, messages); diff != "" {
+		t.Errorf("messages mismatch (-want +got):\n%s", diff)
+	}
+}
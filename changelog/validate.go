@@ -0,0 +1,282 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/giantswarm/microerror"
+)
+
+// dateLayout is the date format of release headers.
+const dateLayout = "2006-01-02"
+
+var (
+	// releaseHeaderRegex matches a complete release header such as
+	// `## [1.2.3] - 2026-10-18`.
+	releaseHeaderRegex  = regexp.MustCompile(`^## \[([^\]]+)\] - (\S+)$`)
+	linkDefinitionRegex = regexp.MustCompile(`^\[([^\]]+)\]:\s*(\S+)`)
+)
+
+// Diagnostic is a problem found in a changelog.
+type Diagnostic struct {
+	// Line is the 1-based line number of the problem.
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d: %s", d.Line, d.Message)
+}
+
+// Validate checks that content follows Keep a Changelog:
+//
+//   - The document starts with a single `# ` title and has no other H1.
+//   - Every H2 is a section header, `## [Unreleased]` or
+//     `## [<version>] - <YYYY-MM-DD>`, for a unique semantic version.
+//   - Unreleased comes first and released versions are in descending order.
+//   - Sections only contain canonical, non-empty, unique H3 categories and
+//     there is no content outside of them.
+//   - Every section has a footer link definition and every link definition
+//     of a version belongs to a section.
+//
+// Diagnostics are returned in line order.
+func Validate(content string) []Diagnostic {
+	doc := Parse(content)
+
+	var diagnostics []Diagnostic
+	report := func(line int, format string, args ...interface{}) {
+		diagnostics = append(diagnostics, Diagnostic{Line: line + 1, Message: fmt.Sprintf(format, args...)})
+	}
+
+	diagnostics = append(diagnostics, doc.validateHeadings()...)
+
+	// Sections.
+	seen := map[string]int{}
+	var previous *semver.Version
+	var previousLine int
+	for i, s := range doc.Sections {
+		header := strings.TrimRight(doc.Lines[s.HeaderLine], " \t")
+
+		if line, ok := seen[s.Version]; ok {
+			report(s.HeaderLine, "duplicate section %#q, first defined at line %d", s.Version, line+1)
+		}
+		seen[s.Version] = s.HeaderLine
+
+		if s.Version == UnreleasedVersion {
+			if header != "## ["+UnreleasedVersion+"]" {
+				report(s.HeaderLine, "section header must be %#q, got %#q", "## ["+UnreleasedVersion+"]", header)
+			}
+			if i != 0 {
+				report(s.HeaderLine, "section %#q must be the first section", UnreleasedVersion)
+			}
+		} else {
+			match := releaseHeaderRegex.FindStringSubmatch(header)
+			if match == nil {
+				report(s.HeaderLine, "section header must be %#q, got %#q", "## ["+s.Version+"] - YYYY-MM-DD", header)
+			} else if _, err := time.Parse(dateLayout, match[2]); err != nil {
+				report(s.HeaderLine, "release date %#q of %#q must be a valid YYYY-MM-DD date", match[2], s.Version)
+			}
+
+			v, err := semver.StrictNewVersion(strings.TrimPrefix(s.Version, "v"))
+			if err != nil {
+				report(s.HeaderLine, "version %#q is not a semantic version", s.Version)
+			} else {
+				if previous != nil && !v.LessThan(previous) {
+					report(s.HeaderLine, "version %#q must be lower than %#q at line %d, versions must be in descending order", s.Version, previous.Original(), previousLine+1)
+				}
+				previous = v
+				previousLine = s.HeaderLine
+			}
+		}
+
+		diagnostics = append(diagnostics, doc.validateBody(s)...)
+	}
+
+	diagnostics = append(diagnostics, doc.validateLinks()...)
+
+	// Stable, so diagnostics of the same line keep the order they were
+	// found in.
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Line < diagnostics[j].Line
+	})
+
+	return diagnostics
+}
+
+// ValidateSince is Validate limited to the sections above the section of
+// version, i.e. the changes since that release, and to the title, preamble
+// and footer. Problems in older sections are ignored, so that changelogs
+// whose history predates the validator can adopt it. An empty version
+// validates every section.
+func ValidateSince(content, version string) ([]Diagnostic, error) {
+	if version == "" {
+		return Validate(content), nil
+	}
+
+	doc := Parse(content)
+
+	s, ok := doc.SectionOf(version)
+	if !ok {
+		return nil, microerror.Maskf(invalidConfigError, "changelog has no section for version %#q", version)
+	}
+
+	var diagnostics []Diagnostic
+	for _, d := range Validate(content) {
+		// Diagnostic lines are 1-based.
+		if d.Line-1 >= s.HeaderLine && d.Line-1 < doc.FooterStart {
+			continue
+		}
+		diagnostics = append(diagnostics, d)
+	}
+
+	return diagnostics, nil
+}
+
+// LatestRelease returns the version of the first released section, i.e. the
+// first one which isn't Unreleased.
+func (d Document) LatestRelease() (string, bool) {
+	for _, s := range d.Sections {
+		if s.Version != UnreleasedVersion {
+			return s.Version, true
+		}
+	}
+	return "", false
+}
+
+// validateHeadings checks the title and that every H2 is a section header.
+func (d Document) validateHeadings() []Diagnostic {
+	var diagnostics []Diagnostic
+
+	title := -1
+	for i, line := range d.Lines[:d.FooterStart] {
+		switch {
+		case strings.HasPrefix(line, "# "):
+			if title >= 0 {
+				diagnostics = append(diagnostics, Diagnostic{Line: i + 1, Message: fmt.Sprintf("only one title is allowed, first defined at line %d", title+1)})
+			} else {
+				title = i
+			}
+		case strings.HasPrefix(line, "## ") && !sectionHeaderRegex.MatchString(line):
+			diagnostics = append(diagnostics, Diagnostic{Line: i + 1, Message: fmt.Sprintf("H2 heading %#q must be a section header like %#q", line, "## [1.2.3] - 2006-01-02")})
+		case strings.HasPrefix(line, "### ") && (len(d.Sections) == 0 || i < d.Sections[0].HeaderLine):
+			diagnostics = append(diagnostics, Diagnostic{Line: i + 1, Message: fmt.Sprintf("category %#q must be inside a section", line)})
+		}
+	}
+
+	first := len(d.Lines)
+	for i, line := range d.Lines {
+		if strings.TrimSpace(line) != "" {
+			first = i
+			break
+		}
+	}
+	if title < 0 {
+		diagnostics = append(diagnostics, Diagnostic{Line: 1, Message: "changelog must start with a title such as `# Changelog`"})
+	} else if title != first {
+		diagnostics = append(diagnostics, Diagnostic{Line: first + 1, Message: "changelog must start with its title"})
+	}
+
+	return diagnostics
+}
+
+// validateBody checks the categories of section s and that there is no
+// content outside of them.
+func (d Document) validateBody(s Section) []Diagnostic {
+	var diagnostics []Diagnostic
+	report := func(line int, format string, args ...interface{}) {
+		diagnostics = append(diagnostics, Diagnostic{Line: line + 1, Message: fmt.Sprintf(format, args...)})
+	}
+
+	seen := map[string]int{}
+	category := -1
+	entries := 0
+	orphan := false
+	endCategory := func() {
+		if category >= 0 && entries == 0 {
+			report(category, "category %#q of %#q is empty", d.Lines[category], s.Version)
+		}
+	}
+
+	for i := s.BodyStart; i < s.BodyEnd; i++ {
+		line := d.Lines[i]
+		if strings.TrimSpace(line) == "" || linkRefLineRegex.MatchString(line) {
+			continue
+		}
+		// Misplaced titles and H2 headings are reported by
+		// validateHeadings.
+		if strings.HasPrefix(line, "# ") || strings.HasPrefix(line, "## ") {
+			continue
+		}
+
+		if name, ok := CategoryOf(line); ok {
+			endCategory()
+			category, entries = i, 0
+
+			if !IsCanonicalCategory(name) {
+				report(i, "category %#q of %#q must be one of Added, Changed, Deprecated, Removed, Fixed, Security", name, s.Version)
+			}
+			if first, ok := seen[name]; ok {
+				report(i, "duplicate category %#q of %#q, first defined at line %d", name, s.Version, first+1)
+			}
+			seen[name] = i
+			continue
+		}
+
+		// Content before the first category is reported once.
+		if category < 0 {
+			if !orphan {
+				report(i, "content of %#q must be under a category (Added, Changed, Deprecated, Removed, Fixed, Security): %#q", s.Version, strings.TrimSpace(line))
+				orphan = true
+			}
+			continue
+		}
+		entries++
+	}
+	endCategory()
+
+	return diagnostics
+}
+
+// validateLinks checks that every section has a link definition and every
+// link definition of a version belongs to a section.
+func (d Document) validateLinks() []Diagnostic {
+	var diagnostics []Diagnostic
+
+	links := map[string]int{}
+	for i, line := range d.Lines {
+		if !linkRefLineRegex.MatchString(line) {
+			continue
+		}
+		match := linkDefinitionRegex.FindStringSubmatch(line)
+		if _, ok := links[match[1]]; ok {
+			diagnostics = append(diagnostics, Diagnostic{Line: i + 1, Message: fmt.Sprintf("duplicate link definition for %#q", match[1])})
+			continue
+		}
+		links[match[1]] = i
+	}
+
+	sections := map[string]bool{}
+	for _, s := range d.Sections {
+		sections[s.Version] = true
+		if _, ok := links[s.Version]; !ok {
+			diagnostics = append(diagnostics, Diagnostic{Line: s.HeaderLine + 1, Message: fmt.Sprintf("section %#q has no link definition such as %#q", s.Version, "["+s.Version+"]: https://...")})
+		}
+	}
+	for version, line := range links {
+		if sections[version] {
+			continue
+		}
+		// Other references, e.g. to Keep a Changelog in the preamble, are
+		// allowed.
+		_, err := semver.StrictNewVersion(strings.TrimPrefix(version, "v"))
+		if err == nil || version == UnreleasedVersion {
+			diagnostics = append(diagnostics, Diagnostic{Line: line + 1, Message: fmt.Sprintf("link definition for %#q has no section", version)})
+		}
+	}
+
+	return diagnostics
+}
@@ -0,0 +1,235 @@
+package changelog
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const validChangelog = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.0.0/).
+
+## [Unreleased]
+
+### Added
+
+- Add feature.
+
+## [1.10.0] - 2026-10-01
+
+### Fixed
+
+- Fix bug,
+  in two lines.
+
+## [1.9.0] - 2026-09-01
+
+### Changed
+
+- Change.
+
+[Unreleased]: https://github.com/giantswarm/my-app/compare/v1.10.0...HEAD
+[1.10.0]: https://github.com/giantswarm/my-app/compare/v1.9.0...v1.10.0
+[1.9.0]: https://github.com/giantswarm/my-app/releases/tag/v1.9.0
+`
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name                string
+		content             string
+		expectedDiagnostics []Diagnostic
+	}{
+		{
+			name:    "case 0: valid changelog",
+			content: validChangelog,
+		},
+		{
+			name: "case 1: structure problems",
+			content: `Intro.
+
+# Changelog
+
+### Added
+
+## Unreleased changes
+
+## [1.0.0] - 2026-10-01
+
+Orphan.
+More orphan.
+
+### Added
+
+### Added
+
+- Entry.
+
+### Misc
+
+- Entry.
+
+## [Unreleased]
+
+# Another title
+
+[Unreleased]: https://github.com/giantswarm/my-app/compare/v1.0.0...HEAD
+[1.0.0]: https://github.com/giantswarm/my-app/releases/tag/v1.0.0
+`,
+			expectedDiagnostics: []Diagnostic{
+				{Line: 1, Message: "changelog must start with its title"},
+				{Line: 5, Message: "category `### Added` must be inside a section"},
+				{Line: 7, Message: "H2 heading `## Unreleased changes` must be a section header like `## [1.2.3] - 2006-01-02`"},
+				{Line: 11, Message: "content of `1.0.0` must be under a category (Added, Changed, Deprecated, Removed, Fixed, Security): `Orphan.`"},
+				{Line: 14, Message: "category `### Added` of `1.0.0` is empty"},
+				{Line: 16, Message: "duplicate category `Added` of `1.0.0`, first defined at line 14"},
+				{Line: 20, Message: "category `Misc` of `1.0.0` must be one of Added, Changed, Deprecated, Removed, Fixed, Security"},
+				{Line: 24, Message: "section `Unreleased` must be the first section"},
+				{Line: 26, Message: "only one title is allowed, first defined at line 3"},
+			},
+		},
+		{
+			name: "case 2: version and date problems",
+			content: `# Changelog
+
+## [Unreleased]
+
+## [1.2.0] - 2026-13-01
+
+## [1.10.0] - 2026-10-01
+
+## [1.10.0] 2026-10-01
+
+## [next] - 2026-10-01
+
+[Unreleased]: https://github.com/giantswarm/my-app/compare/v1.10.0...HEAD
+[1.2.0]: https://github.com/giantswarm/my-app/compare/v1.10.0...v1.2.0
+[1.10.0]: https://github.com/giantswarm/my-app/compare/v1.9.0...v1.10.0
+[next]: https://github.com/giantswarm/my-app/compare/v1.10.0...next
+`,
+			expectedDiagnostics: []Diagnostic{
+				{Line: 5, Message: "release date `2026-13-01` of `1.2.0` must be a valid YYYY-MM-DD date"},
+				{Line: 7, Message: "version `1.10.0` must be lower than `1.2.0` at line 5, versions must be in descending order"},
+				{Line: 9, Message: "duplicate section `1.10.0`, first defined at line 7"},
+				{Line: 9, Message: "section header must be `## [1.10.0] - YYYY-MM-DD`, got `## [1.10.0] 2026-10-01`"},
+				{Line: 9, Message: "version `1.10.0` must be lower than `1.10.0` at line 7, versions must be in descending order"},
+				{Line: 11, Message: "version `next` is not a semantic version"},
+			},
+		},
+		{
+			name: "case 3: link definition problems",
+			content: `# Changelog
+
+See [Keep a Changelog].
+
+## [Unreleased]
+
+## [1.1.0] - 2026-10-01
+
+## [1.0.0] - 2026-09-01
+
+[Keep a Changelog]: https://keepachangelog.com/en/1.0.0/
+[Unreleased]: https://github.com/giantswarm/my-app/compare/v1.1.0...HEAD
+[Unreleased]: https://github.com/giantswarm/my-app/compare/v1.1.0...HEAD
+[1.0.0]: https://github.com/giantswarm/my-app/releases/tag/v1.0.0
+[0.9.0]: https://github.com/giantswarm/my-app/releases/tag/v0.9.0
+`,
+			expectedDiagnostics: []Diagnostic{
+				{Line: 7, Message: "section `1.1.0` has no link definition such as `[1.1.0]: https://...`"},
+				{Line: 13, Message: "duplicate link definition for `Unreleased`"},
+				{Line: 15, Message: "link definition for `0.9.0` has no section"},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			diagnostics := Validate(tc.content)
+			if diff := cmp.Diff(tc.expectedDiagnostics, diagnostics); diff != "" {
+				t.Errorf("diagnostics mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateSince(t *testing.T) {
+	content := `# Changelog
+
+## [Unreleased]
+
+Orphan.
+
+## [1.1.0] - 2026-10-01
+
+Old orphan.
+
+## [1.0.0] 2026-09-01
+
+[Unreleased]: https://github.com/giantswarm/my-app/compare/v1.1.0...HEAD
+[1.1.0]: https://github.com/giantswarm/my-app/compare/v1.0.0...v1.1.0
+[0.9.0]: https://github.com/giantswarm/my-app/releases/tag/v0.9.0
+`
+
+	testCases := []struct {
+		name                string
+		version             string
+		expectedDiagnostics []Diagnostic
+		errorMatcher        func(err error) bool
+	}{
+		{
+			name:    "case 0: every section",
+			version: "",
+			expectedDiagnostics: []Diagnostic{
+				{Line: 5, Message: "content of `Unreleased` must be under a category (Added, Changed, Deprecated, Removed, Fixed, Security): `Orphan.`"},
+				{Line: 9, Message: "content of `1.1.0` must be under a category (Added, Changed, Deprecated, Removed, Fixed, Security): `Old orphan.`"},
+				{Line: 11, Message: "section header must be `## [1.0.0] - YYYY-MM-DD`, got `## [1.0.0] 2026-09-01`"},
+				{Line: 11, Message: "section `1.0.0` has no link definition such as `[1.0.0]: https://...`"},
+				{Line: 15, Message: "link definition for `0.9.0` has no section"},
+			},
+		},
+		{
+			name:    "case 1: since the latest release",
+			version: "v1.1.0",
+			expectedDiagnostics: []Diagnostic{
+				{Line: 5, Message: "content of `Unreleased` must be under a category (Added, Changed, Deprecated, Removed, Fixed, Security): `Orphan.`"},
+				{Line: 15, Message: "link definition for `0.9.0` has no section"},
+			},
+		},
+		{
+			name:         "case 2: missing version",
+			version:      "2.0.0",
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			diagnostics, err := ValidateSince(content, tc.version)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tc.expectedDiagnostics, diagnostics); diff != "" {
+				t.Errorf("diagnostics mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
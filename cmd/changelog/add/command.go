@@ -0,0 +1,14 @@
+package add
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "add MESSAGE",
+		Short: "adds an entry under a category of the Unreleased section of CHANGELOG.md",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runAddError,
+	}
+)
@@ -0,0 +1,13 @@
+package add
+
+import (
+	"github.com/giantswarm/architect/v2/changelog"
+)
+
+func init() {
+	Cmd.Flags().String("file", changelog.FileName, "changelog file, relative to the working directory")
+	Cmd.Flags().String("category", "", "category of the entry. allowed: Added,Changed,Deprecated,Removed,Fixed,Security")
+	Cmd.Flags().Int("pr", 0, "number of the pull request to link from the entry")
+
+	_ = Cmd.MarkFlagRequired("category")
+}
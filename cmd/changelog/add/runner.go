@@ -0,0 +1,103 @@
+package add
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/architect/v2/changelog"
+)
+
+func runAddError(cmd *cobra.Command, args []string) error {
+	var (
+		workingDir = cmd.Flag("working-directory").Value.String()
+		file       = cmd.Flag("file").Value.String()
+		category   = cmd.Flag("category").Value.String()
+		dryRun     bool
+		pr         int
+	)
+	{
+		var err error
+		dryRun, err = strconv.ParseBool(cmd.Flag("dry-run").Value.String())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		pr, err = cmd.Flags().GetInt("pr")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if file == "" {
+		return microerror.Maskf(executionFailedError, "--file flag can't be empty")
+	}
+
+	var repo string
+	{
+		o := cmd.Flag("organisation").Value.String()
+		p := cmd.Flag("project").Value.String()
+		repo = o + "/" + p
+	}
+
+	entry := entryText(args[0], repo, pr)
+
+	path := file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	content, err = changelog.Parse(string(content)).AddEntry(canonicalCategory(category), entry)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if dryRun {
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s", content)
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	err = os.WriteFile(path, content, info.Mode().Perm())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	cmd.Printf("Added %#q under %#q in %#q.\n", entry, canonicalCategory(category), file)
+
+	return nil
+}
+
+// entryText returns message with a link to the pull request pr of repo, if
+// set, appended.
+func entryText(message, repo string, pr int) string {
+	message = strings.TrimSpace(message)
+	if pr <= 0 {
+		return message
+	}
+
+	return fmt.Sprintf("%s ([#%d](https://github.com/%s/pull/%d))", message, pr, repo, pr)
+}
+
+// canonicalCategory returns the canonical spelling of category, which is
+// matched case-insensitively, e.g. `fixed` for `Fixed`.
+func canonicalCategory(category string) changelog.Category {
+	for _, c := range changelog.Categories {
+		if strings.EqualFold(string(c), category) {
+			return c
+		}
+	}
+	return changelog.Category(category)
+}
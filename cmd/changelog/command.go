@@ -0,0 +1,24 @@
+package changelog
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/architect/v2/cmd/changelog/add"
+	"github.com/giantswarm/architect/v2/cmd/changelog/extract"
+	"github.com/giantswarm/architect/v2/cmd/changelog/generate"
+	"github.com/giantswarm/architect/v2/cmd/changelog/validate"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "changelog",
+		Short: "manages CHANGELOG.md",
+	}
+)
+
+func init() {
+	Cmd.AddCommand(add.Cmd)
+	Cmd.AddCommand(extract.Cmd)
+	Cmd.AddCommand(generate.Cmd)
+	Cmd.AddCommand(validate.Cmd)
+}
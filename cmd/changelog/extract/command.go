@@ -0,0 +1,13 @@
+package extract
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "extract",
+		Short: "prints the release notes of a CHANGELOG.md section, e.g. for a GitHub release body",
+		RunE:  runExtractError,
+	}
+)
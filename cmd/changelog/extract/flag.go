@@ -0,0 +1,12 @@
+package extract
+
+import (
+	"github.com/giantswarm/architect/v2/changelog"
+)
+
+func init() {
+	Cmd.Flags().String("file", changelog.FileName, "changelog file, relative to the working directory")
+	Cmd.Flags().String("version", "", "version of the section to extract, e.g. 1.2.3 or v1.2.3. defaults to Unreleased")
+	Cmd.Flags().Bool("link", false, "if true, append the link of the section from the changelog footer, e.g. the compare link of the release")
+	Cmd.Flags().StringP("output", "o", "markdown", "output format. allowed: markdown,text,json")
+}
@@ -0,0 +1,142 @@
+package extract
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/architect/v2/changelog"
+)
+
+type release struct {
+	Version string  `json:"version"`
+	Date    string  `json:"date,omitempty"`
+	Link    string  `json:"link,omitempty"`
+	Changes []group `json:"changes"`
+}
+
+type group struct {
+	Category changelog.Category `json:"category"`
+	Entries  []string           `json:"entries"`
+}
+
+func runExtractError(cmd *cobra.Command, args []string) error {
+	var (
+		workingDir = cmd.Flag("working-directory").Value.String()
+		file       = cmd.Flag("file").Value.String()
+		version    = cmd.Flag("version").Value.String()
+		output     = cmd.Flag("output").Value.String()
+		link       bool
+	)
+	{
+		var err error
+		link, err = cmd.Flags().GetBool("link")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if file == "" {
+		return microerror.Maskf(executionFailedError, "--file flag can't be empty")
+	}
+	if output != "markdown" && output != "text" && output != "json" {
+		return microerror.Maskf(executionFailedError, "unknown output format %q", output)
+	}
+
+	path := file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	doc := changelog.Parse(string(content))
+
+	s, ok := doc.SectionOf(version)
+	if !ok {
+		if version == "" {
+			version = changelog.UnreleasedVersion
+		}
+		return microerror.Maskf(executionFailedError, "%#q has no section for version %#q", file, version)
+	}
+
+	r := release{
+		Version: s.Version,
+		Date:    doc.Date(s),
+		Changes: groupEntries(doc.Entries(s)),
+	}
+	if link {
+		r.Link, _ = doc.Link(s.Version)
+	}
+
+	w := cmd.OutOrStdout()
+	switch output {
+	case "json":
+		data, err := json.MarshalIndent(r, "", "    ")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		_, _ = fmt.Fprintf(w, "%s\n", data)
+	case "text":
+		printText(w, r)
+	default:
+		body := doc.Body(s)
+		if len(body) > 0 {
+			_, _ = fmt.Fprintf(w, "%s\n", strings.Join(body, "\n"))
+		}
+		if r.Link != "" {
+			if len(body) > 0 {
+				_, _ = fmt.Fprintln(w)
+			}
+			_, _ = fmt.Fprintf(w, "**Full Changelog**: %s\n", r.Link)
+		}
+	}
+
+	return nil
+}
+
+// groupEntries groups entries by category in the order the categories first
+// appear in the section.
+func groupEntries(entries []changelog.Entry) []group {
+	groups := []group{}
+	index := map[changelog.Category]int{}
+	for _, e := range entries {
+		i, ok := index[e.Category]
+		if !ok {
+			i = len(groups)
+			index[e.Category] = i
+			groups = append(groups, group{Category: e.Category})
+		}
+		groups[i].Entries = append(groups[i].Entries, e.Text)
+	}
+	return groups
+}
+
+func printText(w io.Writer, r release) {
+	for i, g := range r.Changes {
+		if i > 0 {
+			_, _ = fmt.Fprintln(w)
+		}
+		if g.Category != "" {
+			_, _ = fmt.Fprintf(w, "%s:\n", g.Category)
+		}
+		for _, e := range g.Entries {
+			_, _ = fmt.Fprintf(w, "- %s\n", changelog.PlainText(e))
+		}
+	}
+	if r.Link != "" {
+		if len(r.Changes) > 0 {
+			_, _ = fmt.Fprintln(w)
+		}
+		_, _ = fmt.Fprintf(w, "Full changelog: %s\n", r.Link)
+	}
+}
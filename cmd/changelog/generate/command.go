@@ -0,0 +1,13 @@
+package generate
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "generate",
+		Short: "adds Unreleased entries to CHANGELOG.md for the conventional commits since the previous release tag",
+		RunE:  runGenerateError,
+	}
+)
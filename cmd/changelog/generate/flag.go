@@ -0,0 +1,9 @@
+package generate
+
+import (
+	"github.com/giantswarm/architect/v2/changelog"
+)
+
+func init() {
+	Cmd.Flags().String("file", changelog.FileName, "changelog file, relative to the working directory")
+}
@@ -0,0 +1,79 @@
+package generate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/architect/v2/changelog"
+)
+
+func runGenerateError(cmd *cobra.Command, args []string) error {
+	var (
+		workingDir = cmd.Flag("working-directory").Value.String()
+		file       = cmd.Flag("file").Value.String()
+		dryRun     bool
+	)
+	{
+		var err error
+		dryRun, err = strconv.ParseBool(cmd.Flag("dry-run").Value.String())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if file == "" {
+		return microerror.Maskf(executionFailedError, "--file flag can't be empty")
+	}
+
+	path := file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	tag, commits, err := changelog.CommitsSinceTag(workingDir)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	content, added, err := changelog.Parse(string(content)).MergeEntries(changelog.UnreleasedVersion, changelog.ConventionalEntries(commits))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if dryRun {
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s", content)
+		return nil
+	}
+
+	if len(added) > 0 {
+		info, err := os.Stat(path)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		err = os.WriteFile(path, content, info.Mode().Perm())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	since := "the first commit"
+	if tag != "" {
+		since = fmt.Sprintf("%#q", tag)
+	}
+	for _, e := range added {
+		cmd.Printf("Added %#q under %#q.\n", e.Text, e.Category)
+	}
+	cmd.Printf("Added %d entries to %#q for %d commit(s) since %s.\n", len(added), file, len(commits), since)
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package validate
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "validate",
+		Short: "checks CHANGELOG.md follows Keep a Changelog and reports problems with file and line",
+		RunE:  runValidateError,
+	}
+)
@@ -0,0 +1,15 @@
+package validate
+
+import (
+	"github.com/giantswarm/architect/v2/changelog"
+)
+
+func init() {
+	Cmd.Flags().String("file", changelog.FileName, "changelog file, relative to the working directory")
+	Cmd.Flags().String("fragments-dir", changelog.FragmentsDir, "directory of the changelog fragments to validate, relative to the working directory. empty to skip fragments")
+	Cmd.Flags().String("since", "", "only check the sections above the one of this version, e.g. 1.2.3 or v1.2.3, and ignore problems in older ones")
+	Cmd.Flags().Bool("unreleased", false, "only check the Unreleased section, as with --since set to the latest release, e.g. in pull request checks")
+	Cmd.Flags().StringP("output", "o", "text", "output format. allowed: text,json")
+
+	Cmd.MarkFlagsMutuallyExclusive("since", "unreleased")
+}
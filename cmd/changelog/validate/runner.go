@@ -0,0 +1,144 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/architect/v2/changelog"
+)
+
+type report struct {
+	File        string                 `json:"file"`
+	Diagnostics []changelog.Diagnostic `json:"diagnostics"`
+	Fragments   []report               `json:"fragments,omitempty"`
+}
+
+func runValidateError(cmd *cobra.Command, args []string) error {
+	var (
+		workingDir = cmd.Flag("working-directory").Value.String()
+		file       = cmd.Flag("file").Value.String()
+		fragments  = cmd.Flag("fragments-dir").Value.String()
+		since      = cmd.Flag("since").Value.String()
+		output     = cmd.Flag("output").Value.String()
+		unreleased bool
+	)
+	{
+		var err error
+		unreleased, err = cmd.Flags().GetBool("unreleased")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if file == "" {
+		return microerror.Maskf(executionFailedError, "--file flag can't be empty")
+	}
+	if output != "text" && output != "json" {
+		return microerror.Maskf(executionFailedError, "unknown output format %q", output)
+	}
+
+	path := file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if unreleased {
+		// Without any release yet there is nothing to leave out.
+		since, _ = changelog.Parse(string(content)).LatestRelease()
+	}
+
+	diagnostics, err := changelog.ValidateSince(string(content), since)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r := report{
+		File:        file,
+		Diagnostics: diagnostics,
+	}
+	if r.Diagnostics == nil {
+		r.Diagnostics = []changelog.Diagnostic{}
+	}
+	problems := len(r.Diagnostics)
+
+	if fragments != "" {
+		r.Fragments, err = validateFragments(workingDir, fragments)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		for _, f := range r.Fragments {
+			problems += len(f.Diagnostics)
+		}
+	}
+
+	switch output {
+	case "json":
+		data, err := json.MarshalIndent(r, "", "    ")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s\n", data)
+	default:
+		for _, rr := range append([]report{r}, r.Fragments...) {
+			for _, d := range rr.Diagnostics {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s:%s\n", rr.File, d)
+			}
+		}
+	}
+
+	if problems > 0 {
+		return microerror.Maskf(executionFailedError, "%d changelog problem(s) in %#q and its fragments", problems, file)
+	}
+
+	return nil
+}
+
+// validateFragments returns a report for every fragment in dir. Files which
+// aren't named like fragments, e.g. a README.md, are ignored. A missing dir
+// has no fragments.
+func validateFragments(workingDir, dir string) ([]report, error) {
+	path := dir
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+
+	entries, err := os.ReadDir(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var reports []report
+	for _, e := range entries {
+		if e.IsDir() || !changelog.IsFragmentName(e.Name()) {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(path, e.Name()))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		_, diagnostics := changelog.ParseFragment(e.Name(), string(content))
+		if diagnostics == nil {
+			diagnostics = []changelog.Diagnostic{}
+		}
+		reports = append(reports, report{
+			File:        filepath.Join(dir, e.Name()),
+			Diagnostics: diagnostics,
+		})
+	}
+
+	return reports, nil
+}
@@ -3,7 +3,15 @@ package helm
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/giantswarm/architect/v2/cmd/helm/images"
+	"github.com/giantswarm/architect/v2/cmd/helm/index"
+	"github.com/giantswarm/architect/v2/cmd/helm/lintmetadata"
+	"github.com/giantswarm/architect/v2/cmd/helm/packagechart"
+	"github.com/giantswarm/architect/v2/cmd/helm/policy"
 	"github.com/giantswarm/architect/v2/cmd/helm/template"
+	"github.com/giantswarm/architect/v2/cmd/helm/untemplate"
+	"github.com/giantswarm/architect/v2/cmd/helm/valuesdiff"
+	"github.com/giantswarm/architect/v2/cmd/helm/verify"
 )
 
 var (
@@ -14,5 +22,13 @@ var (
 )
 
 func init() {
+	Cmd.AddCommand(images.Cmd)
+	Cmd.AddCommand(index.Cmd)
+	Cmd.AddCommand(lintmetadata.Cmd)
+	Cmd.AddCommand(packagechart.Cmd)
+	Cmd.AddCommand(policy.Cmd)
 	Cmd.AddCommand(template.Cmd)
+	Cmd.AddCommand(untemplate.Cmd)
+	Cmd.AddCommand(valuesdiff.Cmd)
+	Cmd.AddCommand(verify.Cmd)
 }
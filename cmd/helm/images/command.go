@@ -0,0 +1,13 @@
+package images
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "images",
+		Short: "renders helm chart with values.yaml and ci/*.yaml and lists the container images it deploys",
+		RunE:  runImagesError,
+	}
+)
@@ -0,0 +1,9 @@
+package images
+
+func init() {
+	Cmd.Flags().String("dir", "", "helm chart directory")
+	Cmd.Flags().String("release-name", "", "release name the chart is rendered with (default \"release-name\")")
+	Cmd.Flags().String("namespace", "", "namespace the chart is rendered in (default \"default\")")
+	Cmd.Flags().String("kube-version", "", "Kubernetes version the chart is rendered for (default the Helm SDK's)")
+	Cmd.Flags().StringP("output", "o", "text", "output format. allowed: text,json")
+}
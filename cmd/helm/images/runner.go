@@ -0,0 +1,78 @@
+package images
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/architect/v2/helmimages"
+)
+
+func runImagesError(cmd *cobra.Command, args []string) error {
+	var (
+		chartDir    = cmd.Flag("dir").Value.String()
+		releaseName = cmd.Flag("release-name").Value.String()
+		namespace   = cmd.Flag("namespace").Value.String()
+		kubeVersion = cmd.Flag("kube-version").Value.String()
+		output      = cmd.Flag("output").Value.String()
+	)
+
+	if chartDir == "" {
+		return microerror.Maskf(executionFailedError, "--dir flag can't be empty")
+	}
+	if output != "text" && output != "json" {
+		return microerror.Maskf(executionFailedError, "unknown output format %q", output)
+	}
+
+	var t *helmimages.ListImagesTask
+	{
+		c := helmimages.Config{
+			Fs:          afero.NewOsFs(),
+			ChartDir:    chartDir,
+			ReleaseName: releaseName,
+			Namespace:   namespace,
+			KubeVersion: kubeVersion,
+		}
+
+		var err error
+		t, err = helmimages.NewListImagesTask(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	log.Printf("listing helm chart images\n%s\n", t)
+
+	images, err := t.Run()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(images, "", "    ")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s\n", data)
+	} else {
+		printImages(cmd.OutOrStdout(), images)
+	}
+
+	return nil
+}
+
+// printImages prints every image followed by the containers using it.
+func printImages(w io.Writer, images []helmimages.Image) {
+	for _, image := range images {
+		_, _ = fmt.Fprintf(w, "%s\n", image.Reference)
+		for _, s := range image.Sources {
+			_, _ = fmt.Fprintf(w, "  %s (%s)\n", s, strings.Join(s.ValuesFiles, ", "))
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package index
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "index PACKAGE...",
+		Short: "merges packaged helm charts into a helm repository index",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runIndexError,
+	}
+)
@@ -0,0 +1,6 @@
+package index
+
+func init() {
+	Cmd.Flags().String("index", "index.yaml", "helm repository index to merge the chart packages into, created if missing")
+	Cmd.Flags().String("url", "", "base URL of the chart packages in the helm repository")
+}
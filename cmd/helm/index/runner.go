@@ -0,0 +1,62 @@
+package index
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/architect/v2/helmpackage"
+)
+
+func runIndexError(cmd *cobra.Command, args []string) error {
+	var (
+		indexFile  = cmd.Flag("index").Value.String()
+		baseURL    = cmd.Flag("url").Value.String()
+		workingDir = cmd.Flag("working-directory").Value.String()
+	)
+
+	if indexFile == "" {
+		return microerror.Maskf(executionFailedError, "--index flag can't be empty")
+	}
+
+	created, err := helmpackage.SourceDate(workingDir)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var t *helmpackage.IndexTask
+	{
+		c := helmpackage.IndexConfig{
+			Fs:        afero.NewOsFs(),
+			IndexFile: indexFile,
+			Packages:  args,
+			BaseURL:   baseURL,
+			Created:   created,
+		}
+
+		t, err = helmpackage.NewIndexTask(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	log.Printf("indexing helm chart packages\n%s\n", t)
+
+	entries, err := t.Run()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, e := range entries {
+		status := "added"
+		if !e.Added {
+			status = "unchanged"
+		}
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s %s %s %s\n", e.Name, e.Version, e.Digest, status)
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package lintmetadata
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "lint-metadata",
+		Short: "checks Chart.yaml against Giant Swarm chart metadata conventions",
+		Long: `Checks Chart.yaml against Giant Swarm chart metadata conventions.
+
+The untemplated Chart.yaml of the repository is linted as is. Unrendered
+[[ ]] placeholders such as "version: [[ .Version ]]" are accepted as the
+version and app version, and their values are only checked by
+"helm template --validate --lint-metadata" once they are rendered.`,
+		RunE: runLintMetadataError,
+	}
+)
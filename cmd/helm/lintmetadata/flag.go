@@ -0,0 +1,6 @@
+package lintmetadata
+
+func init() {
+	Cmd.Flags().String("dir", "", "helm chart directory")
+	Cmd.Flags().StringP("output", "o", "text", "output format. allowed: text,json")
+}
@@ -0,0 +1,68 @@
+package lintmetadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/architect/v2/helmtemplate"
+)
+
+type report struct {
+	Chart    string                         `json:"chart"`
+	Findings []helmtemplate.MetadataFinding `json:"findings"`
+}
+
+func runLintMetadataError(cmd *cobra.Command, args []string) error {
+	var (
+		chartDir = cmd.Flag("dir").Value.String()
+		output   = cmd.Flag("output").Value.String()
+	)
+
+	if chartDir == "" {
+		return microerror.Maskf(executionFailedError, "--dir flag can't be empty")
+	}
+
+	content, err := os.ReadFile(filepath.Join(chartDir, helmtemplate.HelmChartYamlName))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	findings, err := helmtemplate.LintMetadata(chartDir, content)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	switch output {
+	case "json":
+		r := report{
+			Chart:    chartDir,
+			Findings: findings,
+		}
+		if r.Findings == nil {
+			r.Findings = []helmtemplate.MetadataFinding{}
+		}
+
+		data, err := json.MarshalIndent(r, "", "    ")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s\n", data)
+	case "text":
+		for _, f := range findings {
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", filepath.Join(chartDir, helmtemplate.HelmChartYamlName), f)
+		}
+	default:
+		return microerror.Maskf(executionFailedError, "unknown output format %q", output)
+	}
+
+	if len(findings) > 0 {
+		return microerror.Maskf(executionFailedError, "%d chart metadata finding(s) in %#q", len(findings), chartDir)
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package packagechart
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "package",
+		Short: "packages templated helm chart reproducibly",
+		RunE:  runPackageError,
+	}
+)
@@ -0,0 +1,6 @@
+package packagechart
+
+func init() {
+	Cmd.Flags().String("dir", "", "helm chart directory")
+	Cmd.Flags().String("destination", ".", "directory the chart package is written to")
+}
@@ -0,0 +1,55 @@
+package packagechart
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/architect/v2/helmpackage"
+)
+
+func runPackageError(cmd *cobra.Command, args []string) error {
+	var (
+		chartDir    = cmd.Flag("dir").Value.String()
+		destination = cmd.Flag("destination").Value.String()
+		workingDir  = cmd.Flag("working-directory").Value.String()
+	)
+
+	if chartDir == "" {
+		return microerror.Maskf(executionFailedError, "--dir flag can't be empty")
+	}
+
+	modTime, err := helmpackage.SourceDate(workingDir)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var t *helmpackage.PackageChartTask
+	{
+		c := helmpackage.Config{
+			Fs:          afero.NewOsFs(),
+			ChartDir:    chartDir,
+			Destination: destination,
+			ModTime:     modTime,
+		}
+
+		t, err = helmpackage.NewPackageChartTask(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	log.Printf("packaging helm chart\n%s\n", t)
+
+	r, err := t.Run()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s  %s\n", r.Digest, r.Path)
+
+	return nil
+}
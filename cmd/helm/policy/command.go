@@ -0,0 +1,13 @@
+package policy
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "policy",
+		Short: "renders helm chart with values.yaml and ci/*.yaml and checks resources against Rego policies",
+		RunE:  runPolicyError,
+	}
+)
@@ -0,0 +1,13 @@
+package policy
+
+func init() {
+	Cmd.Flags().String("dir", "", "helm chart directory")
+	Cmd.Flags().StringArray("policy-dir", nil, "directory with Rego policies defining deny and warn rules, loaded recursively (can be repeated)")
+	Cmd.Flags().Bool("no-default-policies", false, "only evaluate the policies from --policy-dir")
+	Cmd.Flags().Bool("fail-on-warn", false, "fail on warnings as well as on denials")
+	Cmd.Flags().String("rego-version", "v0", "Rego version policies are parsed with, as conftest policies without `import rego.v1` are v0. allowed: v0,v1")
+	Cmd.Flags().String("release-name", "", "release name the chart is rendered with (default \"release-name\")")
+	Cmd.Flags().String("namespace", "", "namespace the chart is rendered in (default \"default\")")
+	Cmd.Flags().String("kube-version", "", "Kubernetes version the chart is rendered for (default the Helm SDK's)")
+	Cmd.Flags().StringP("output", "o", "text", "output format. allowed: text,json,junit")
+}
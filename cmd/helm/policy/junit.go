@@ -0,0 +1,102 @@
+package policy
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/architect/v2/helmpolicy"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Error     *junitMessage   `xml:"error,omitempty"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes the reports as a JUnit XML document with a test suite
+// per values file and a test case per resource. Warnings are failures with
+// failOnWarn and written to the test case output otherwise.
+func writeJUnit(w io.Writer, chartDir string, reports []helmpolicy.Report, failOnWarn bool) error {
+	suites := junitTestSuites{Name: chartDir}
+
+	for _, r := range reports {
+		suite := junitTestSuite{Name: r.ValuesFile}
+		if r.Error != "" {
+			suite.Errors = 1
+			suite.Error = &junitMessage{Message: "rendering failed", Text: r.Error}
+		}
+
+		for _, res := range r.Resources {
+			tc := junitTestCase{
+				Name:      fmt.Sprintf("%s/%s", res.Kind, res.Name),
+				ClassName: res.Template,
+			}
+
+			failures := append([]helmpolicy.Finding{}, res.Failures...)
+			if failOnWarn {
+				failures = append(failures, res.Warnings...)
+			} else if len(res.Warnings) > 0 {
+				tc.SystemOut = findingLines("WARN", res.Warnings)
+			}
+			if len(failures) > 0 {
+				tc.Failure = &junitMessage{
+					Message: fmt.Sprintf("%d policy violation(s)", len(failures)),
+					Text:    findingLines("FAIL", failures),
+				}
+				suite.Failures++
+			}
+
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+		suite.Tests = len(suite.TestCases)
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	data, err := xml.MarshalIndent(suites, "", "    ")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	_, _ = fmt.Fprintf(w, "%s%s\n", xml.Header, data)
+
+	return nil
+}
+
+func findingLines(level string, findings []helmpolicy.Finding) string {
+	lines := make([]string, len(findings))
+	for i, f := range findings {
+		lines[i] = fmt.Sprintf("%s %s [%s]", level, f.Message, f.Policy)
+	}
+	return strings.Join(lines, "\n")
+}
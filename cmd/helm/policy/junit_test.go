@@ -0,0 +1,90 @@
+package policy
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/giantswarm/architect/v2/helmpolicy"
+)
+
+func Test_writeJUnit(t *testing.T) {
+	reports := []helmpolicy.Report{
+		{
+			ValuesFile: "values.yaml",
+			Failed:     true,
+			Resources: []helmpolicy.ResourceReport{
+				{
+					Template: "my-app/templates/deployment.yaml", Kind: "Deployment", Name: "my-app",
+					Failures: []helmpolicy.Finding{{Policy: "architect.images", Message: "latest tag"}},
+					Warnings: []helmpolicy.Finding{{Policy: "architect.pss", Message: "runs as root"}},
+				},
+				{Template: "my-app/templates/service.yaml", Kind: "Service", Name: "my-app"},
+			},
+		},
+		{
+			ValuesFile: "ci/broken-values.yaml",
+			Failed:     true,
+			Error:      "render failed",
+		},
+	}
+
+	testCases := []struct {
+		name       string
+		failOnWarn bool
+		expected   string
+	}{
+		{
+			name: "case 0: warnings as output",
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="helm/my-app" tests="2" failures="1" errors="1">
+    <testsuite name="values.yaml" tests="2" failures="1" errors="0">
+        <testcase name="Deployment/my-app" classname="my-app/templates/deployment.yaml">
+            <failure message="1 policy violation(s)">FAIL latest tag [architect.images]</failure>
+            <system-out>WARN runs as root [architect.pss]</system-out>
+        </testcase>
+        <testcase name="Service/my-app" classname="my-app/templates/service.yaml"></testcase>
+    </testsuite>
+    <testsuite name="ci/broken-values.yaml" tests="0" failures="0" errors="1">
+        <error message="rendering failed">render failed</error>
+    </testsuite>
+</testsuites>
+`,
+		},
+		{
+			name:       "case 1: warnings as failures",
+			failOnWarn: true,
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="helm/my-app" tests="2" failures="1" errors="1">
+    <testsuite name="values.yaml" tests="2" failures="1" errors="0">
+        <testcase name="Deployment/my-app" classname="my-app/templates/deployment.yaml">
+            <failure message="2 policy violation(s)">FAIL latest tag [architect.images]&#xA;FAIL runs as root [architect.pss]</failure>
+        </testcase>
+        <testcase name="Service/my-app" classname="my-app/templates/service.yaml"></testcase>
+    </testsuite>
+    <testsuite name="ci/broken-values.yaml" tests="0" failures="0" errors="1">
+        <error message="rendering failed">render failed</error>
+    </testsuite>
+</testsuites>
+`,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			var buf bytes.Buffer
+			err := writeJUnit(&buf, "helm/my-app", reports, tc.failOnWarn)
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, buf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
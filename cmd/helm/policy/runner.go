@@ -0,0 +1,133 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/architect/v2/helmpolicy"
+)
+
+func runPolicyError(cmd *cobra.Command, args []string) error {
+	var (
+		chartDir          = cmd.Flag("dir").Value.String()
+		releaseName       = cmd.Flag("release-name").Value.String()
+		namespace         = cmd.Flag("namespace").Value.String()
+		kubeVersion       = cmd.Flag("kube-version").Value.String()
+		output            = cmd.Flag("output").Value.String()
+		regoVersion       = cmd.Flag("rego-version").Value.String()
+		noDefaultPolicies bool
+		failOnWarn        bool
+	)
+	{
+		var err error
+		noDefaultPolicies, err = strconv.ParseBool(cmd.Flag("no-default-policies").Value.String())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		failOnWarn, err = strconv.ParseBool(cmd.Flag("fail-on-warn").Value.String())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if chartDir == "" {
+		return microerror.Maskf(executionFailedError, "--dir flag can't be empty")
+	}
+	if output != "text" && output != "json" && output != "junit" {
+		return microerror.Maskf(executionFailedError, "unknown output format %q", output)
+	}
+
+	policyDirs, err := cmd.Flags().GetStringArray("policy-dir")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var t *helmpolicy.CheckChartTask
+	{
+		c := helmpolicy.Config{
+			Fs:                afero.NewOsFs(),
+			ChartDir:          chartDir,
+			PolicyDirs:        policyDirs,
+			NoDefaultPolicies: noDefaultPolicies,
+			FailOnWarn:        failOnWarn,
+			RegoVersion:       regoVersion,
+			ReleaseName:       releaseName,
+			Namespace:         namespace,
+			KubeVersion:       kubeVersion,
+		}
+
+		t, err = helmpolicy.NewCheckChartTask(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	log.Printf("checking helm chart policies\n%s\n", t)
+
+	reports, err := t.Run()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	switch output {
+	case "json":
+		data, err := json.MarshalIndent(reports, "", "    ")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s\n", data)
+	case "junit":
+		err = writeJUnit(cmd.OutOrStdout(), chartDir, reports, failOnWarn)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	default:
+		printReports(cmd.OutOrStdout(), reports)
+	}
+
+	var failed []string
+	for _, r := range reports {
+		if r.Failed {
+			failed = append(failed, r.ValuesFile)
+		}
+	}
+	if len(failed) > 0 {
+		return microerror.Maskf(executionFailedError, "chart %#q failed policy checks with %s", chartDir, strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// printReports prints the findings per values file and per resource.
+// Resources without findings are omitted.
+func printReports(w io.Writer, reports []helmpolicy.Report) {
+	for _, r := range reports {
+		_, _ = fmt.Fprintf(w, "%s\n", r.ValuesFile)
+
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(w, "  ERROR rendering failed: %s\n", r.Error)
+			continue
+		}
+
+		var failures, warnings int
+		for _, res := range r.Resources {
+			for _, f := range res.Failures {
+				_, _ = fmt.Fprintf(w, "  FAIL %s (%s) [%s]\n", f.Message, res.Template, f.Policy)
+			}
+			for _, f := range res.Warnings {
+				_, _ = fmt.Fprintf(w, "  WARN %s (%s) [%s]\n", f.Message, res.Template, f.Policy)
+			}
+			failures += len(res.Failures)
+			warnings += len(res.Warnings)
+		}
+		_, _ = fmt.Fprintf(w, "  %d resources, %d failures, %d warnings\n", len(r.Resources), failures, warnings)
+	}
+}
@@ -0,0 +1,187 @@
+package template
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/pelletier/go-toml/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// Kinds of app version sources accepted by --app-version-source.
+const (
+	appVersionSourceCargo       = "cargo"
+	appVersionSourceFile        = "file"
+	appVersionSourceGo          = "go"
+	appVersionSourcePackageJSON = "package-json"
+	appVersionSourcePyproject   = "pyproject"
+	appVersionSourceYAML        = "yaml"
+)
+
+// defaultAppVersionSources preserves the historical behaviour of reading the
+// `version` variable of pkg/project/project.go.
+var defaultAppVersionSources = []string{"go:pkg/project/project.go:version"}
+
+// appVersionSource describes where the version of the application packaged
+// by a chart is stored in the repository.
+type appVersionSource struct {
+	Kind string
+	// Path is the file, relative to the repository root.
+	Path string
+	// Keys are the dotted paths of the value in a structured file, or the
+	// variable name in a Go file, tried in order.
+	Keys []string
+}
+
+func (s appVersionSource) String() string {
+	if len(s.Keys) == 0 {
+		return s.Path
+	}
+	return s.Path + ":" + strings.Join(s.Keys, "|")
+}
+
+// parseAppVersionSource parses a source in KIND[:PATH[:KEY]] form. PATH and
+// KEY default to the conventional location of the version for the given kind.
+func parseAppVersionSource(spec string) (appVersionSource, error) {
+	parts := strings.SplitN(spec, ":", 3)
+	for len(parts) < 3 {
+		parts = append(parts, "")
+	}
+	kind, path, key := parts[0], parts[1], parts[2]
+
+	var s appVersionSource
+	switch kind {
+	case appVersionSourceCargo:
+		s = appVersionSource{Path: "Cargo.toml", Keys: []string{"package.version", "workspace.package.version"}}
+	case appVersionSourceFile:
+		if key != "" {
+			return appVersionSource{}, microerror.Maskf(invalidFlagError, "app version source %#q: %#q sources take no key", spec, kind)
+		}
+		s = appVersionSource{Path: "VERSION"}
+	case appVersionSourceGo:
+		s = appVersionSource{Path: "pkg/project/project.go", Keys: []string{"version"}}
+	case appVersionSourcePackageJSON:
+		s = appVersionSource{Path: "package.json", Keys: []string{"version"}}
+	case appVersionSourcePyproject:
+		s = appVersionSource{Path: "pyproject.toml", Keys: []string{"project.version", "tool.poetry.version"}}
+	case appVersionSourceYAML:
+		if path == "" || key == "" {
+			return appVersionSource{}, microerror.Maskf(invalidFlagError, "app version source %#q: %#q sources must be in %s:PATH:KEY form", spec, kind, kind)
+		}
+	default:
+		return appVersionSource{}, microerror.Maskf(invalidFlagError, "app version source %#q: unknown kind %#q, allowed: %s,%s,%s,%s,%s,%s", spec, kind, appVersionSourceCargo, appVersionSourceFile, appVersionSourceGo, appVersionSourcePackageJSON, appVersionSourcePyproject, appVersionSourceYAML)
+	}
+
+	s.Kind = kind
+	if path != "" {
+		s.Path = path
+	}
+	if key != "" {
+		s.Keys = []string{key}
+	}
+
+	return s, nil
+}
+
+// getAppVersion tries the given sources in order and returns the first version
+// found along with the source it was read from. A source whose file doesn't
+// exist or doesn't hold the version is skipped. If no source holds the version
+// it returns empty strings.
+func getAppVersion(repoDir string, specs []string) (string, string, error) {
+	for _, spec := range specs {
+		s, err := parseAppVersionSource(spec)
+		if err != nil {
+			return "", "", microerror.Mask(err)
+		}
+
+		content, err := os.ReadFile(filepath.Clean(filepath.Join(repoDir, s.Path)))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", "", microerror.Mask(err)
+		}
+
+		version, err := readAppVersion(repoDir, s, content)
+		if IsUnresolvableVersion(err) {
+			return "", "", microerror.Mask(err)
+		} else if err != nil {
+			return "", "", microerror.Maskf(executionFailedError, "reading app version from %#q: %s", s.Path, err)
+		}
+		if version != "" {
+			return version, s.String(), nil
+		}
+	}
+
+	return "", "", nil
+}
+
+// readAppVersion returns the version held by content of the file described by
+// s, or an empty string if it isn't there.
+func readAppVersion(repoDir string, s appVersionSource, content []byte) (string, error) {
+	if s.Kind == appVersionSourceFile {
+		return strings.TrimSpace(string(content)), nil
+	}
+
+	if s.Kind == appVersionSourceGo {
+		for _, key := range s.Keys {
+			version, err := resolveGoString(filepath.Join(repoDir, s.Path), content, key)
+			if IsLinkTimeVersion(err) {
+				// Versions stamped at build time can't be checked.
+				log.Printf("not reading app version from %s: %s", s.Path, err)
+				continue
+			} else if err != nil {
+				return "", microerror.Mask(err)
+			}
+			if version != "" {
+				return version, nil
+			}
+		}
+
+		return "", nil
+	}
+
+	var doc map[string]interface{}
+	{
+		var err error
+		switch s.Kind {
+		case appVersionSourceCargo, appVersionSourcePyproject:
+			err = toml.Unmarshal(content, &doc)
+		case appVersionSourcePackageJSON:
+			err = json.Unmarshal(content, &doc)
+		default:
+			err = yaml.Unmarshal(content, &doc)
+		}
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+	}
+
+	for _, key := range s.Keys {
+		if version := lookupString(doc, key); version != "" {
+			return version, nil
+		}
+	}
+
+	return "", nil
+}
+
+// lookupString returns the string found at the dotted path key in doc, or an
+// empty string if there is none.
+func lookupString(doc map[string]interface{}, key string) string {
+	var value interface{} = doc
+	for _, k := range strings.Split(key, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = m[k]
+	}
+
+	s, _ := value.(string)
+
+	return strings.TrimSpace(s)
+}
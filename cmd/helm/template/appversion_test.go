@@ -0,0 +1,168 @@
+package template
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/giantswarm/gitsemver/v2/pkg/gitsemver"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/architect/v2/pkg/project"
+)
+
+// TestGetAppVersion tests getAppVersion method which retrieves the app version
+// from the first of the configured sources holding it.
+func TestGetAppVersion(t *testing.T) {
+	t.Parallel()
+
+	architectGitTopLevelDir, err := gitsemver.TopLevel(".")
+	if err != nil {
+		t.Fatalf("err = %#q, want %#v", microerror.JSON(err), nil)
+	}
+
+	testCases := []struct {
+		name            string
+		inputDir        string
+		files           map[string]string
+		sources         []string
+		expectedVersion string
+		expectedSource  string
+		errorMatcher    func(err error) bool
+	}{
+		{
+			name:            "case 0: default source in this repository",
+			inputDir:        architectGitTopLevelDir,
+			sources:         defaultAppVersionSources,
+			expectedVersion: project.Version(),
+			expectedSource:  "pkg/project/project.go:version",
+		},
+		{
+			name:            "case 1: default source in non-existent directory",
+			inputDir:        path.Join(architectGitTopLevelDir, "non-exitent"),
+			sources:         defaultAppVersionSources,
+			expectedVersion: "",
+		},
+		{
+			name: "case 2: VERSION file",
+			files: map[string]string{
+				"VERSION": "1.2.3\n",
+			},
+			sources:         []string{"go", "file"},
+			expectedVersion: "1.2.3",
+			expectedSource:  "VERSION",
+		},
+		{
+			name: "case 3: package.json",
+			files: map[string]string{
+				"package.json": `{"name": "my-app", "version": "2.0.0"}`,
+			},
+			sources:         []string{"package-json"},
+			expectedVersion: "2.0.0",
+			expectedSource:  "package.json:version",
+		},
+		{
+			name: "case 4: poetry pyproject.toml",
+			files: map[string]string{
+				"pyproject.toml": "[tool.poetry]\nname = \"my-app\"\nversion = \"0.3.0\"\n",
+			},
+			sources:         []string{"pyproject"},
+			expectedVersion: "0.3.0",
+			expectedSource:  "pyproject.toml:project.version|tool.poetry.version",
+		},
+		{
+			name: "case 5: Cargo.toml in a sub directory",
+			files: map[string]string{
+				"rust/Cargo.toml": "[package]\nname = \"my-app\"\nversion = \"4.5.6\"\n",
+			},
+			sources:         []string{"cargo:rust/Cargo.toml"},
+			expectedVersion: "4.5.6",
+			expectedSource:  "rust/Cargo.toml:package.version|workspace.package.version",
+		},
+		{
+			name: "case 6: YAML path, first source without the version is skipped",
+			files: map[string]string{
+				"package.json":   `{"name": "my-app"}`,
+				"meta/info.yaml": "app:\n  version: 7.8.9\n",
+			},
+			sources:         []string{"package-json", "yaml:meta/info.yaml:app.version"},
+			expectedVersion: "7.8.9",
+			expectedSource:  "meta/info.yaml:app.version",
+		},
+		{
+			name: "case 7: Go variable in arbitrary file",
+			files: map[string]string{
+				"internal/build/build.go": "package build\n\nvar AppVersion = \"3.0.0\"\n",
+			},
+			sources:         []string{"go:internal/build/build.go:AppVersion"},
+			expectedVersion: "3.0.0",
+			expectedSource:  "internal/build/build.go:AppVersion",
+		},
+		{
+			name:         "case 8: unknown kind",
+			sources:      []string{"gradle"},
+			errorMatcher: IsInvalidFlag,
+		},
+		{
+			name:         "case 9: YAML source without key",
+			sources:      []string{"yaml:values.yaml"},
+			errorMatcher: IsInvalidFlag,
+		},
+		{
+			name: "case 10: Go variable set at link time is skipped",
+			files: map[string]string{
+				"pkg/project/project.go": "package project\n\nvar version string\n",
+				"VERSION":                "4.0.0\n",
+			},
+			sources:         []string{"go:pkg/project/project.go:version", "file:VERSION"},
+			expectedVersion: "4.0.0",
+			expectedSource:  "VERSION",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			dir := tc.inputDir
+			if dir == "" {
+				dir = t.TempDir()
+				for name, content := range tc.files {
+					p := filepath.Join(dir, name)
+					err := os.MkdirAll(filepath.Dir(p), 0755)
+					if err != nil {
+						t.Fatal(err)
+					}
+					err = os.WriteFile(p, []byte(content), 0600)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			version, source, err := getAppVersion(dir, tc.sources)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if err == nil {
+				if version != tc.expectedVersion {
+					t.Errorf("got %#q, expected %#q\n", version, tc.expectedVersion)
+				}
+				if source != tc.expectedSource {
+					t.Errorf("got source %#q, expected %#q\n", source, tc.expectedSource)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/yaml"
+)
+
+// getBuildInfoExtra merges user defined build information from, in order of
+// increasing precedence, a YAML/JSON file, environment variables starting
+// with envPrefix (which is stripped from the key) and key=value pairs.
+func getBuildInfoExtra(file, envPrefix string, environ, pairs []string) (map[string]string, error) {
+	extra := map[string]string{}
+
+	if file != "" {
+		content, err := os.ReadFile(filepath.Clean(file))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		var fromFile map[string]string
+		err = yaml.Unmarshal(content, &fromFile)
+		if err != nil {
+			return nil, microerror.Maskf(invalidFlagError, "build info file %#q must contain a map of strings: %s", file, err)
+		}
+
+		for k, v := range fromFile {
+			extra[k] = v
+		}
+	}
+
+	if envPrefix != "" {
+		for _, env := range environ {
+			k, v, _ := strings.Cut(env, "=")
+			if !strings.HasPrefix(k, envPrefix) || k == envPrefix {
+				continue
+			}
+			extra[strings.TrimPrefix(k, envPrefix)] = v
+		}
+	}
+
+	for _, pair := range pairs {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || k == "" {
+			return nil, microerror.Maskf(invalidFlagError, "build info %#q must be in key=value form", pair)
+		}
+		extra[k] = v
+	}
+
+	return extra, nil
+}
@@ -0,0 +1,93 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetBuildInfoExtra(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "build-info.yaml")
+	err := os.WriteFile(file, []byte("registry: quay.io\nbuildTimestamp: \"2026-01-02\"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name          string
+		file          string
+		envPrefix     string
+		environ       []string
+		pairs         []string
+		expectedExtra map[string]string
+		errorMatcher  func(err error) bool
+	}{
+		{
+			name:          "case 0: no sources",
+			expectedExtra: map[string]string{},
+		},
+		{
+			name:  "case 1: key=value pairs",
+			pairs: []string{"registry=gsoci.azurecr.io", "pipelineURL=https://ci.example.com/?a=b"},
+			expectedExtra: map[string]string{
+				"registry":    "gsoci.azurecr.io",
+				"pipelineURL": "https://ci.example.com/?a=b",
+			},
+		},
+		{
+			name:      "case 2: environment variables with prefix",
+			envPrefix: "ARCHITECT_",
+			environ:   []string{"HOME=/root", "ARCHITECT_registry=gsoci.azurecr.io", "ARCHITECT_="},
+			expectedExtra: map[string]string{
+				"registry": "gsoci.azurecr.io",
+			},
+		},
+		{
+			name:      "case 3: pairs override environment which overrides file",
+			file:      file,
+			envPrefix: "ARCHITECT_",
+			environ:   []string{"ARCHITECT_registry=docker.io", "ARCHITECT_digest=sha256:abc"},
+			pairs:     []string{"digest=sha256:def"},
+			expectedExtra: map[string]string{
+				"registry":       "docker.io",
+				"buildTimestamp": "2026-01-02",
+				"digest":         "sha256:def",
+			},
+		},
+		{
+			name:         "case 4: invalid pair",
+			pairs:        []string{"registry"},
+			errorMatcher: IsInvalidFlag,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			extra, err := getBuildInfoExtra(tc.file, tc.envPrefix, tc.environ, tc.pairs)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if err == nil {
+				if diff := cmp.Diff(tc.expectedExtra, extra); diff != "" {
+					t.Errorf("extra mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
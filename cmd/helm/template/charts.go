@@ -0,0 +1,96 @@
+package template
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/yaml"
+)
+
+// chartsConfig is the format of the --charts-config file, e.g.
+//
+//	charts:
+//	  helm/my-operator-crds:
+//	    version: 2.1.0
+//
+// Charts are keyed by their directory relative to --charts-root. Charts
+// without an entry are built with the repository version.
+type chartsConfig struct {
+	Charts map[string]chartOverride `json:"charts"`
+}
+
+type chartOverride struct {
+	// Version overrides the version the chart is built with.
+	Version string `json:"version"`
+	// AppVersion overrides the chart's appVersion. It defaults to Version.
+	AppVersion string `json:"appVersion"`
+}
+
+type chartResult struct {
+	Dir     string
+	Version string
+	Err     error
+}
+
+// getChartOverrides reads the per-chart overrides from file. An empty file
+// name results in no overrides.
+func getChartOverrides(file string) (map[string]chartOverride, error) {
+	if file == "" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var c chartsConfig
+	err = yaml.UnmarshalStrict(content, &c)
+	if err != nil {
+		return nil, microerror.Maskf(invalidFlagError, "invalid charts config %#q: %s", file, err)
+	}
+
+	overrides := map[string]chartOverride{}
+	for dir, o := range c.Charts {
+		if o.Version == "" && o.AppVersion != "" {
+			return nil, microerror.Maskf(invalidFlagError, "chart %#q in %#q sets appVersion without version", dir, file)
+		}
+		overrides[filepath.ToSlash(filepath.Clean(dir))] = o
+	}
+
+	return overrides, nil
+}
+
+// printChartResults writes a table with the outcome of templating each chart.
+func printChartResults(w io.Writer, results []chartResult) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	_, err := fmt.Fprintln(tw, "CHART\tVERSION\tSTATUS\tERROR")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, r := range results {
+		status, msg := "ok", ""
+		if r.Err != nil {
+			status, msg = "failed", strings.ReplaceAll(microerror.Pretty(r.Err, false), "\n", " ")
+		}
+
+		_, err = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Dir, r.Version, status, msg)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	err = tw.Flush()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetChartOverrides(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name              string
+		config            string
+		expectedOverrides map[string]chartOverride
+		errorMatcher      func(err error) bool
+	}{
+		{
+			name:   "case 0: overrides keyed by clean chart directory",
+			config: "charts:\n  helm/crds/:\n    version: 2.1.0\n  ./helm/app:\n    version: 1.0.0\n    appVersion: 0.9.0\n",
+			expectedOverrides: map[string]chartOverride{
+				"helm/crds": {Version: "2.1.0"},
+				"helm/app":  {Version: "1.0.0", AppVersion: "0.9.0"},
+			},
+		},
+		{
+			name:         "case 1: appVersion without version",
+			config:       "charts:\n  helm/app:\n    appVersion: 0.9.0\n",
+			errorMatcher: IsInvalidFlag,
+		},
+		{
+			name:         "case 2: unknown field",
+			config:       "charts:\n  helm/app:\n    verison: 0.9.0\n",
+			errorMatcher: IsInvalidFlag,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			file := filepath.Join(t.TempDir(), "charts.yaml")
+			err := os.WriteFile(file, []byte(tc.config), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			overrides, err := getChartOverrides(file)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if err == nil {
+				if diff := cmp.Diff(tc.expectedOverrides, overrides); diff != "" {
+					t.Errorf("overrides mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/architect/v2/helmtemplate"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+type diffSummary struct {
+	Chart string                  `json:"chart"`
+	Files []helmtemplate.FileDiff `json:"files"`
+}
+
+// printDiffs writes the dry-run report of the chart in chartDir to w, either
+// as concatenated unified diffs or as a JSON summary of the changed keys per
+// file.
+func printDiffs(w io.Writer, output, chartDir string, diffs []helmtemplate.FileDiff) error {
+	switch output {
+	case outputJSON:
+		summary := diffSummary{
+			Chart: chartDir,
+			Files: diffs,
+		}
+		if summary.Files == nil {
+			summary.Files = []helmtemplate.FileDiff{}
+		}
+
+		data, err := json.MarshalIndent(summary, "", "    ")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	default:
+		for _, d := range diffs {
+			_, err := fmt.Fprint(w, d.UnifiedDiff)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package template
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+// executionFailedError should never be matched against and therefore there is
+// no matcher implement. For further information see:
+//
+//	https://github.com/giantswarm/fmt/blob/master/go/errors.md#matching-errors
+var executionFailedError = &microerror.Error{
+	Kind: "executionFailedError",
+}
+
+var invalidFlagError = &microerror.Error{
+	Kind: "invalidFlagError",
+}
+
+// IsInvalidFlag asserts invalidFlagError.
+func IsInvalidFlag(err error) bool {
+	return microerror.Cause(err) == invalidFlagError
+}
+
+// linkTimeVersionError is returned for versions held by a Go variable declared
+// without a value, which is usually set at link time with `-ldflags -X`.
+var linkTimeVersionError = &microerror.Error{
+	Kind: "linkTimeVersionError",
+}
+
+// IsLinkTimeVersion asserts linkTimeVersionError.
+func IsLinkTimeVersion(err error) bool {
+	return microerror.Cause(err) == linkTimeVersionError
+}
+
+var unresolvableVersionError = &microerror.Error{
+	Kind: "unresolvableVersionError",
+}
+
+// IsUnresolvableVersion asserts unresolvableVersionError.
+func IsUnresolvableVersion(err error) bool {
+	return microerror.Cause(err) == unresolvableVersionError
+}
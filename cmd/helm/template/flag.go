@@ -1,7 +1,33 @@
 package template
 
+import (
+	"github.com/giantswarm/architect/v2/helmtemplate"
+)
+
 func init() {
 	Cmd.Flags().String("dir", "", "helm chart directory")
+	Cmd.Flags().String("charts-root", "", "template every chart found under this directory instead of a single --dir")
+	Cmd.Flags().String("charts-config", "", "YAML file with per-chart version overrides for --charts-root")
 	Cmd.Flags().Bool("validate", false, "enables chart validation")
 	Cmd.Flags().Bool("tag-build", false, "should be set when validating a tagged build")
+	Cmd.Flags().Bool("lint-metadata", false, "when validating, also check Chart.yaml against Giant Swarm chart metadata conventions")
+	Cmd.Flags().StringP("output", "o", "text", "dry-run report format. allowed: text,json")
+	Cmd.Flags().Bool("strict", false, "fail with file and line on any unresolved [[ ]] reference, before templating any file")
+	Cmd.Flags().StringArray("set-build-info", nil, "extra build info in key=value form, accessible as [[ .Extra.key ]] (can be repeated)")
+	Cmd.Flags().String("build-info-env-prefix", "", "import environment variables with this prefix as extra build info, with the prefix stripped from the key")
+	Cmd.Flags().StringArray("app-version-source", defaultAppVersionSources, "where to read the app version from, in KIND[:PATH[:KEY]] form, tried in order (can be repeated). kinds: cargo,file,go,package-json,pyproject,yaml")
+	Cmd.Flags().String("build-info-file", "", "YAML or JSON file with extra build info key/value pairs")
+	Cmd.Flags().Bool("validate-images", false, "check registry, repository and tag of the image references in values.yaml against --registry, --image-repository and the version being built")
+	Cmd.Flags().String("image-repository", "", "expected repository of image references (default <organisation>/<project>)")
+	Cmd.Flags().StringSlice("image-paths", helmtemplate.DefaultImagePaths, "dotted paths of image references in values.yaml, * matches every key of a mapping")
+	Cmd.Flags().Bool("rewrite-registry", false, "set the registry of image references in values.yaml to --registry")
+	Cmd.Flags().String("changelog", "", "CHANGELOG.md to generate the artifacthub.io/changes annotation of Chart.yaml from, using the section of the version being built")
+	Cmd.Flags().Bool("journal", false, "record the original content of the templated files inside the git directory of the repository, so `helm untemplate` can restore them")
+	Cmd.Flags().String("metadata-file", "", "write the build info and the files modified by templating, with their checksums, to this file")
+	Cmd.Flags().String("metadata-format", "json", "format of --metadata-file. allowed: json,dotenv")
+	Cmd.Flags().Bool("github-output", false, "append the build metadata as step outputs to the file named by $GITHUB_OUTPUT")
+	Cmd.Flags().Bool("bash-env", false, "append the build metadata as exported ARCHITECT_* variables to the file named by $BASH_ENV")
+
+	Cmd.MarkFlagsMutuallyExclusive("dir", "charts-root")
+	Cmd.MarkFlagsOneRequired("dir", "charts-root")
 }
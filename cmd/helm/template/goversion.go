@@ -0,0 +1,201 @@
+package template
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+// goValue is a package level variable or constant declaration.
+type goValue struct {
+	// Expr is the declared value, nil if the declaration has none.
+	Expr ast.Expr
+	// Pos is the position of the declared name, for error messages.
+	Pos token.Position
+}
+
+// goPackage holds the package level declarations needed to resolve a string
+// variable or constant of a Go package.
+type goPackage struct {
+	values map[string]goValue
+	types  map[string]bool
+}
+
+// resolveGoString returns the string value of the package level variable or
+// constant name declared in the Go file at path, whose content is given. The
+// value may be a string literal, a reference to another variable or constant
+// of the package, a conversion to a string type or a concatenation of those.
+// If name isn't declared it returns an empty string. If its value depends on
+// a variable declared without a value, which is only known once set at link
+// time with `-ldflags -X`, it returns a linkTimeVersionError, and if it
+// can't be determined statically otherwise an unresolvableVersionError.
+func resolveGoString(path string, content []byte, name string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.SkipObjectResolution)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	pkg := goPackage{
+		values: map[string]goValue{},
+		types:  map[string]bool{},
+	}
+
+	// Identifiers may refer to declarations in other files of the package.
+	// Declarations of the given file are added last so they win over
+	// duplicates in files for other platforms.
+	{
+		dir := filepath.Dir(path)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		for _, e := range entries {
+			p := filepath.Join(dir, e.Name())
+			if e.IsDir() || filepath.Ext(p) != ".go" || strings.HasSuffix(p, "_test.go") || p == filepath.Clean(path) {
+				continue
+			}
+
+			other, err := parser.ParseFile(fset, p, nil, parser.SkipObjectResolution)
+			if err != nil {
+				return "", microerror.Mask(err)
+			}
+			if other.Name.Name != file.Name.Name {
+				continue
+			}
+			pkg.add(fset, other)
+		}
+	}
+	pkg.add(fset, file)
+
+	if _, ok := pkg.values[name]; !ok {
+		return "", nil
+	}
+
+	return pkg.resolve(name, map[string]bool{})
+}
+
+// add records the package level declarations of file.
+func (p goPackage) add(fset *token.FileSet, file *ast.File) {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+
+		// Within a const group a spec without values repeats the values of
+		// the previous one.
+		var previous []ast.Expr
+		for _, spec := range gen.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				p.types[s.Name.Name] = true
+			case *ast.ValueSpec:
+				values := s.Values
+				if gen.Tok == token.CONST {
+					if len(values) == 0 {
+						values = previous
+					}
+					previous = values
+				}
+
+				for i, id := range s.Names {
+					v := goValue{Pos: fset.Position(id.Pos())}
+					// Values of a multi-value assignment, e.g. a function
+					// call, can't be attributed to a single name.
+					if len(values) == len(s.Names) {
+						v.Expr = values[i]
+					} else if len(values) > 0 {
+						v.Expr = &ast.BadExpr{From: values[0].Pos(), To: values[len(values)-1].End()}
+					}
+					p.values[id.Name] = v
+				}
+			}
+		}
+	}
+}
+
+// resolve returns the string value of the declaration name. seen holds the
+// names being resolved to detect reference cycles.
+func (p goPackage) resolve(name string, seen map[string]bool) (string, error) {
+	v := p.values[name]
+	if seen[name] {
+		return "", microerror.Maskf(unresolvableVersionError, "%s: %#q refers to itself", v.Pos, name)
+	}
+	seen[name] = true
+	defer delete(seen, name)
+
+	// Only variables can be declared without a value.
+	if v.Expr == nil {
+		return "", microerror.Maskf(linkTimeVersionError, "%s: %#q is declared without a value, it is only known once set at link time", v.Pos, name)
+	}
+
+	value, err := p.eval(v.Expr, seen)
+	if IsLinkTimeVersion(err) {
+		return "", microerror.Mask(err)
+	} else if err != nil {
+		return "", microerror.Maskf(unresolvableVersionError, "%s: resolving %#q: %s", v.Pos, name, err)
+	}
+
+	return value, nil
+}
+
+// eval returns the string value of expr.
+func (p goPackage) eval(expr ast.Expr, seen map[string]bool) (string, error) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", microerror.Maskf(unresolvableVersionError, "%s is not a string literal", e.Value)
+		}
+		value, err := strconv.Unquote(e.Value)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+		return value, nil
+
+	case *ast.Ident:
+		if _, ok := p.values[e.Name]; !ok {
+			return "", microerror.Maskf(unresolvableVersionError, "%#q is not a variable or constant of the package", e.Name)
+		}
+		value, err := p.resolve(e.Name, seen)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+		return value, nil
+
+	case *ast.ParenExpr:
+		return p.eval(e.X, seen)
+
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", microerror.Maskf(unresolvableVersionError, "unsupported operator %s", e.Op)
+		}
+		x, err := p.eval(e.X, seen)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+		y, err := p.eval(e.Y, seen)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+		return x + y, nil
+
+	case *ast.CallExpr:
+		// Only conversions such as string(x) or Version(x) are evaluated,
+		// anything else is only known at run time.
+		fun, ok := e.Fun.(*ast.Ident)
+		if ok && len(e.Args) == 1 && (fun.Name == "string" || p.types[fun.Name]) {
+			return p.eval(e.Args[0], seen)
+		}
+		return "", microerror.Maskf(unresolvableVersionError, "function calls are only known at run time")
+	}
+
+	return "", microerror.Maskf(unresolvableVersionError, "unsupported expression of type %T", expr)
+}
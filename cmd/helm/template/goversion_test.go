@@ -0,0 +1,153 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// TestResolveGoString tests resolving the value of a Go string variable or
+// constant statically.
+func TestResolveGoString(t *testing.T) {
+	testCases := []struct {
+		name string
+		// files are the files of the package, the version is looked up in
+		// project.go.
+		files         map[string]string
+		varName       string
+		expectedValue string
+		errorMatcher  func(err error) bool
+	}{
+		{
+			name: "case 0: var with string literal",
+			files: map[string]string{
+				"project.go": "package project\n\nvar version = \"1.0.0\"\n",
+			},
+			varName:       "version",
+			expectedValue: "1.0.0",
+		},
+		{
+			name: "case 1: typed const",
+			files: map[string]string{
+				"project.go": "package project\n\nconst version string = \"1.0.0\"\n",
+			},
+			varName:       "version",
+			expectedValue: "1.0.0",
+		},
+		{
+			name: "case 2: grouped declaration with computed values",
+			files: map[string]string{
+				"project.go": "package project\n\nvar (\n\tdescription = \"The architect.\"\n\tname, version = \"architect\", prefix + base\n)\n\nconst (\n\tprefix = \"v\"\n\tbase = \"1.0.0\"\n)\n",
+			},
+			varName:       "version",
+			expectedValue: "v1.0.0",
+		},
+		{
+			name: "case 3: reference to constant in another file",
+			files: map[string]string{
+				"project.go": "package project\n\nvar version = Version(release)\n\ntype Version string\n",
+				"release.go": "package project\n\nconst release = \"2.0.0\"\n",
+				"other.go":   "package other\n\nconst release = \"3.0.0\"\n",
+			},
+			varName:       "version",
+			expectedValue: "2.0.0",
+		},
+		{
+			name: "case 4: const repeating previous value in group",
+			files: map[string]string{
+				"project.go": "package project\n\nconst (\n\tgitVersion = \"1.2.3\"\n\tversion\n)\n",
+			},
+			varName:       "version",
+			expectedValue: "1.2.3",
+		},
+		{
+			name: "case 5: variable not declared",
+			files: map[string]string{
+				"project.go": "package project\n\nvar name = \"architect\"\n",
+			},
+			varName:       "version",
+			expectedValue: "",
+		},
+		{
+			name: "case 6: variable declared without value",
+			files: map[string]string{
+				"project.go": "package project\n\nvar version string\n",
+			},
+			varName:      "version",
+			errorMatcher: IsLinkTimeVersion,
+		},
+		{
+			name: "case 7: value only known at run time",
+			files: map[string]string{
+				"project.go": "package project\n\nimport \"os\"\n\nvar version = os.Getenv(\"VERSION\")\n",
+			},
+			varName:      "version",
+			errorMatcher: IsUnresolvableVersion,
+		},
+		{
+			name: "case 8: multi-value assignment",
+			files: map[string]string{
+				"project.go": "package project\n\nvar version, commit = info()\n",
+			},
+			varName:      "version",
+			errorMatcher: IsUnresolvableVersion,
+		},
+		{
+			name: "case 9: reference cycle",
+			files: map[string]string{
+				"project.go": "package project\n\nvar version = other\n\nvar other = version\n",
+			},
+			varName:      "version",
+			errorMatcher: IsUnresolvableVersion,
+		},
+		{
+			name: "case 10: non-string constant",
+			files: map[string]string{
+				"project.go": "package project\n\nconst version = 1\n",
+			},
+			varName:      "version",
+			errorMatcher: IsUnresolvableVersion,
+		},
+		{
+			name: "case 11: reference to variable declared without value",
+			files: map[string]string{
+				"project.go": "package project\n\nvar version = \"v\" + gitVersion\n\nvar gitVersion string\n",
+			},
+			varName:      "version",
+			errorMatcher: IsLinkTimeVersion,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			path := filepath.Join(dir, "project.go")
+			value, err := resolveGoString(path, []byte(tc.files["project.go"]), tc.varName)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if value != tc.expectedValue {
+				t.Errorf("got %#q, expected %#q\n", value, tc.expectedValue)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/architect/v2/helmtemplate"
+)
+
+const (
+	metadataFormatDotenv = "dotenv"
+	metadataFormatJSON   = "json"
+
+	// metadataEnvPrefix prefixes the names of dotenv and $BASH_ENV variables.
+	metadataEnvPrefix = "ARCHITECT_"
+
+	githubOutputEnv = "GITHUB_OUTPUT"
+	bashEnvEnv      = "BASH_ENV"
+)
+
+// invalidEnvNameCharRegexp matches the characters which aren't allowed in
+// environment variable names.
+var invalidEnvNameCharRegexp = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// buildMetadata is the build information of a helm template run, for
+// consumption by later CI steps.
+type buildMetadata struct {
+	Branch     string            `json:"branch"`
+	SHA        string            `json:"sha"`
+	Tag        string            `json:"tag"`
+	Version    string            `json:"version"`
+	AppVersion string            `json:"appVersion"`
+	Extra      map[string]string `json:"extra,omitempty"`
+	// Charts holds the versions of every templated chart, which may differ
+	// from Version and AppVersion when templating with --charts-config.
+	Charts []chartMetadata `json:"charts"`
+	// ModifiedFiles are the files changed by templating, relative to the
+	// working directory, across all charts.
+	ModifiedFiles []helmtemplate.TemplatedFile `json:"modifiedFiles"`
+}
+
+type chartMetadata struct {
+	Dir        string `json:"dir"`
+	Version    string `json:"version"`
+	AppVersion string `json:"appVersion"`
+}
+
+// add records the chart templated with config c into result r.
+func (m *buildMetadata) add(c helmtemplate.Config, r helmtemplate.Result) {
+	m.Charts = append(m.Charts, chartMetadata{
+		Dir:        c.ChartDir,
+		Version:    r.BuildInfo.Version,
+		AppVersion: r.BuildInfo.AppVersion,
+	})
+
+	for _, f := range r.ModifiedFiles {
+		m.ModifiedFiles = append(m.ModifiedFiles, helmtemplate.TemplatedFile{
+			Path:   path.Join(filepath.ToSlash(c.ChartDir), f.Path),
+			SHA256: f.SHA256,
+		})
+	}
+}
+
+// metadataVariable is a single build metadata value exported as a variable.
+type metadataVariable struct {
+	// Name is the upper snake case name of the variable, without prefix.
+	Name  string
+	Value string
+}
+
+// variables flattens m into variables. Extra keys are upper cased with
+// characters invalid in variable names replaced by `_`, and keys which end up
+// with the same name, e.g. `foo` and `FOO`, are rejected. Modified files and
+// their checksums are exported as a JSON array.
+func (m buildMetadata) variables() ([]metadataVariable, error) {
+	modifiedFiles := m.ModifiedFiles
+	if modifiedFiles == nil {
+		modifiedFiles = []helmtemplate.TemplatedFile{}
+	}
+	files, err := json.Marshal(modifiedFiles)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	vars := []metadataVariable{
+		{Name: "BRANCH", Value: m.Branch},
+		{Name: "SHA", Value: m.SHA},
+		{Name: "TAG", Value: m.Tag},
+		{Name: "VERSION", Value: m.Version},
+		{Name: "APP_VERSION", Value: m.AppVersion},
+	}
+
+	keys := make([]string, 0, len(m.Extra))
+	for k := range m.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	names := map[string]string{}
+	for _, k := range keys {
+		name := "EXTRA_" + invalidEnvNameCharRegexp.ReplaceAllString(strings.ToUpper(k), "_")
+		if other, ok := names[name]; ok {
+			return nil, microerror.Maskf(invalidFlagError, "extra build info keys %#q and %#q are both exported as %s%s", other, k, metadataEnvPrefix, name)
+		}
+		names[name] = k
+		vars = append(vars, metadataVariable{Name: name, Value: m.Extra[k]})
+	}
+
+	vars = append(vars, metadataVariable{Name: "MODIFIED_FILES", Value: string(files)})
+
+	return vars, nil
+}
+
+// writeMetadata writes m to w in the given format, JSON or dotenv.
+func writeMetadata(w io.Writer, format string, m buildMetadata) error {
+	if format == metadataFormatJSON {
+		if m.Charts == nil {
+			m.Charts = []chartMetadata{}
+		}
+		if m.ModifiedFiles == nil {
+			m.ModifiedFiles = []helmtemplate.TemplatedFile{}
+		}
+
+		data, err := json.MarshalIndent(m, "", "    ")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	return writeEnv(w, "", m)
+}
+
+// writeEnv writes m as shell compatible variable assignments, each preceded
+// by prefix, e.g. "export ".
+func writeEnv(w io.Writer, prefix string, m buildMetadata) error {
+	vars, err := m.variables()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, v := range vars {
+		_, err := fmt.Fprintf(w, "%s%s%s=%s\n", prefix, metadataEnvPrefix, v.Name, shellQuote(v.Value))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// writeGithubOutput writes m as GitHub Actions step outputs with lower snake
+// case names. Multi-line values use the heredoc syntax.
+func writeGithubOutput(w io.Writer, m buildMetadata) error {
+	vars, err := m.variables()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, v := range vars {
+		name := strings.ToLower(v.Name)
+		if !strings.Contains(v.Value, "\n") {
+			_, err = fmt.Fprintf(w, "%s=%s\n", name, v.Value)
+		} else {
+			sum := sha256.Sum256([]byte(v.Value))
+			delimiter := "ghadelimiter_" + hex.EncodeToString(sum[:8])
+			_, err = fmt.Fprintf(w, "%s<<%s\n%s\n%s\n", name, delimiter, v.Value, delimiter)
+		}
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// shellQuote single quotes s for the shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// exportMetadata writes m to file, when set, and appends it to the files named
+// by $GITHUB_OUTPUT and $BASH_ENV when requested.
+func exportMetadata(m buildMetadata, file, format string, githubOutput, bashEnv bool) error {
+	if file != "" {
+		f, err := os.Create(filepath.Clean(file))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		defer f.Close()
+
+		err = writeMetadata(f, format, m)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		err = f.Close()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if githubOutput {
+		err := appendToEnvFile(githubOutputEnv, func(w io.Writer) error {
+			return writeGithubOutput(w, m)
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if bashEnv {
+		err := appendToEnvFile(bashEnvEnv, func(w io.Writer) error {
+			return writeEnv(w, "export ", m)
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// appendToEnvFile appends what write writes to the file named by the
+// environment variable env.
+func appendToEnvFile(env string, write func(w io.Writer) error) error {
+	file := os.Getenv(env)
+	if file == "" {
+		return microerror.Maskf(invalidFlagError, "$%s must be set", env)
+	}
+
+	f, err := os.OpenFile(filepath.Clean(file), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer f.Close()
+
+	err = write(f)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	err = f.Close()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
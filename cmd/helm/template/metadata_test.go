@@ -0,0 +1,190 @@
+package template
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/giantswarm/architect/v2/helmtemplate"
+)
+
+// TestWriteMetadata tests build metadata is written in every supported format.
+func TestWriteMetadata(t *testing.T) {
+	metadata := buildMetadata{
+		Branch:     "main",
+		SHA:        "ea82e754178bb2b8065aca0a0760e77ce3733649",
+		Tag:        "v1.2.3",
+		Version:    "1.2.3",
+		AppVersion: "1.2.3",
+		Extra: map[string]string{
+			"pipeline": "https://ci.example.com/1",
+			"notes":    "it's\nmulti-line",
+		},
+	}
+	metadata.add(
+		helmtemplate.Config{ChartDir: "helm/my-app"},
+		helmtemplate.Result{
+			BuildInfo: helmtemplate.BuildInfo{Version: "1.2.3", AppVersion: "1.2.3"},
+			ModifiedFiles: []helmtemplate.TemplatedFile{
+				{Path: "Chart.yaml", SHA256: "0123"},
+			},
+		},
+	)
+
+	testCases := []struct {
+		name           string
+		write          func(w *bytes.Buffer) error
+		expectedOutput string
+	}{
+		{
+			name: "case 0: json",
+			write: func(w *bytes.Buffer) error {
+				return writeMetadata(w, metadataFormatJSON, metadata)
+			},
+			expectedOutput: `{
+    "branch": "main",
+    "sha": "ea82e754178bb2b8065aca0a0760e77ce3733649",
+    "tag": "v1.2.3",
+    "version": "1.2.3",
+    "appVersion": "1.2.3",
+    "extra": {
+        "notes": "it's\nmulti-line",
+        "pipeline": "https://ci.example.com/1"
+    },
+    "charts": [
+        {
+            "dir": "helm/my-app",
+            "version": "1.2.3",
+            "appVersion": "1.2.3"
+        }
+    ],
+    "modifiedFiles": [
+        {
+            "path": "helm/my-app/Chart.yaml",
+            "sha256": "0123"
+        }
+    ]
+}
+`,
+		},
+		{
+			name: "case 1: dotenv",
+			write: func(w *bytes.Buffer) error {
+				return writeMetadata(w, metadataFormatDotenv, metadata)
+			},
+			expectedOutput: `ARCHITECT_BRANCH='main'
+ARCHITECT_SHA='ea82e754178bb2b8065aca0a0760e77ce3733649'
+ARCHITECT_TAG='v1.2.3'
+ARCHITECT_VERSION='1.2.3'
+ARCHITECT_APP_VERSION='1.2.3'
+ARCHITECT_EXTRA_NOTES='it'\''s
+multi-line'
+ARCHITECT_EXTRA_PIPELINE='https://ci.example.com/1'
+ARCHITECT_MODIFIED_FILES='[{"path":"helm/my-app/Chart.yaml","sha256":"0123"}]'
+`,
+		},
+		{
+			name: "case 2: GitHub step outputs",
+			write: func(w *bytes.Buffer) error {
+				return writeGithubOutput(w, metadata)
+			},
+			expectedOutput: `branch=main
+sha=ea82e754178bb2b8065aca0a0760e77ce3733649
+tag=v1.2.3
+version=1.2.3
+app_version=1.2.3
+extra_notes<<ghadelimiter_a38875e68a65e5f2
+it's
+multi-line
+ghadelimiter_a38875e68a65e5f2
+extra_pipeline=https://ci.example.com/1
+modified_files=[{"path":"helm/my-app/Chart.yaml","sha256":"0123"}]
+`,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			var buf bytes.Buffer
+			err := tc.write(&buf)
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+
+			if diff := cmp.Diff(tc.expectedOutput, buf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestMetadataVariables tests extra keys are exported with valid and unique
+// variable names.
+func TestMetadataVariables(t *testing.T) {
+	testCases := []struct {
+		name          string
+		extra         map[string]string
+		expectedNames []string
+		errorMatcher  func(err error) bool
+	}{
+		{
+			name: "case 0: keys sanitized",
+			extra: map[string]string{
+				"buildTimestamp": "2026-01-02T03:04:05Z",
+				"ci.url":         "https://ci.example.com/1",
+				"image-tag":      "1.2.3",
+			},
+			expectedNames: []string{"BRANCH", "SHA", "TAG", "VERSION", "APP_VERSION", "EXTRA_BUILDTIMESTAMP", "EXTRA_CI_URL", "EXTRA_IMAGE_TAG", "MODIFIED_FILES"},
+		},
+		{
+			name: "case 1: keys differing in case",
+			extra: map[string]string{
+				"foo": "a",
+				"FOO": "b",
+			},
+			errorMatcher: IsInvalidFlag,
+		},
+		{
+			name: "case 2: keys differing in invalid characters",
+			extra: map[string]string{
+				"a-b": "a",
+				"a.b": "b",
+			},
+			errorMatcher: IsInvalidFlag,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			vars, err := buildMetadata{Extra: tc.extra}.variables()
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+			if err != nil {
+				return
+			}
+
+			var names []string
+			for _, v := range vars {
+				names = append(names, v.Name)
+			}
+			if diff := cmp.Diff(tc.expectedNames, names); diff != "" {
+				t.Errorf("names mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,9 +1,7 @@
 package template
 
 import (
-	"go/ast"
-	"go/parser"
-	"go/token"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -19,13 +17,30 @@ import (
 
 func runTemplateError(cmd *cobra.Command, args []string) (err error) {
 	var (
-		chartDir = cmd.Flag("dir").Value.String()
-		branch   = cmd.Flag("branch").Value.String()
-		sha      = cmd.Flag("sha").Value.String()
-		tag      = cmd.Flag("tag").Value.String()
-		version  = cmd.Flag("version").Value.String()
-		validate bool
-		tagBuild bool
+		chartDir     = cmd.Flag("dir").Value.String()
+		chartsRoot   = cmd.Flag("charts-root").Value.String()
+		chartsConfig = cmd.Flag("charts-config").Value.String()
+		branch       = cmd.Flag("branch").Value.String()
+		sha          = cmd.Flag("sha").Value.String()
+		tag          = cmd.Flag("tag").Value.String()
+		version      = cmd.Flag("version").Value.String()
+		validate     bool
+		tagBuild     bool
+		strict       bool
+		lintMetadata bool
+		dryRun       bool
+		journal      bool
+		output       = cmd.Flag("output").Value.String()
+		changelog    = cmd.Flag("changelog").Value.String()
+		metaFile     = cmd.Flag("metadata-file").Value.String()
+		metaFormat   = cmd.Flag("metadata-format").Value.String()
+		githubOutput bool
+		bashEnv      bool
+
+		validateImages  bool
+		rewriteRegistry bool
+		registry        = cmd.Flag("registry").Value.String()
+		imageRepository = cmd.Flag("image-repository").Value.String()
 	)
 	{
 		var err error
@@ -37,11 +52,96 @@ func runTemplateError(cmd *cobra.Command, args []string) (err error) {
 		if err != nil {
 			return microerror.Mask(err)
 		}
+		strict, err = strconv.ParseBool(cmd.Flag("strict").Value.String())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		journal, err = strconv.ParseBool(cmd.Flag("journal").Value.String())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		lintMetadata, err = strconv.ParseBool(cmd.Flag("lint-metadata").Value.String())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		dryRun, err = strconv.ParseBool(cmd.Flag("dry-run").Value.String())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		githubOutput, err = strconv.ParseBool(cmd.Flag("github-output").Value.String())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		bashEnv, err = strconv.ParseBool(cmd.Flag("bash-env").Value.String())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		validateImages, err = strconv.ParseBool(cmd.Flag("validate-images").Value.String())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		rewriteRegistry, err = strconv.ParseBool(cmd.Flag("rewrite-registry").Value.String())
+		if err != nil {
+			return microerror.Mask(err)
+		}
 	}
 
-	fs := afero.NewOsFs()
+	imagePaths, err := cmd.Flags().GetStringSlice("image-paths")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if imageRepository == "" {
+		imageRepository = cmd.Flag("organisation").Value.String() + "/" + cmd.Flag("project").Value.String()
+	}
 
-	var appVersion string
+	if output != outputText && output != outputJSON {
+		return microerror.Maskf(invalidFlagError, "--output must be one of %#q or %#q, got %#q", outputText, outputJSON, output)
+	}
+	if metaFormat != metadataFormatJSON && metaFormat != metadataFormatDotenv {
+		return microerror.Maskf(invalidFlagError, "--metadata-format must be one of %#q or %#q, got %#q", metadataFormatJSON, metadataFormatDotenv, metaFormat)
+	}
+	// Fail before templating anything when the metadata can't be exported.
+	if githubOutput && os.Getenv(githubOutputEnv) == "" {
+		return microerror.Maskf(invalidFlagError, "--github-output requires $%s to be set", githubOutputEnv)
+	}
+	if bashEnv && os.Getenv(bashEnvEnv) == "" {
+		return microerror.Maskf(invalidFlagError, "--bash-env requires $%s to be set", bashEnvEnv)
+	}
+
+	var extra map[string]string
+	{
+		pairs, err := cmd.Flags().GetStringArray("set-build-info")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		file := cmd.Flag("build-info-file").Value.String()
+		envPrefix := cmd.Flag("build-info-env-prefix").Value.String()
+
+		extra, err = getBuildInfoExtra(file, envPrefix, os.Environ(), pairs)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		// Extra keys colliding as variable names fail the export, which
+		// must happen before templating anything.
+		if (metaFile != "" && metaFormat == metadataFormatDotenv) || githubOutput || bashEnv {
+			_, err = buildMetadata{Extra: extra}.variables()
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	// In dry-run mode the chart is templated into an in-memory layer over the
+	// read-only chart directory, which is then diffed against the original.
+	base := afero.NewOsFs()
+	fs := base
+	if dryRun {
+		fs = afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(base), afero.NewMemMapFs())
+	}
+
+	var appVersion, appVersionSource string
 	skipAppVersionCheck := false
 	{
 		dir, err := gitsemver.TopLevel(".")
@@ -49,98 +149,239 @@ func runTemplateError(cmd *cobra.Command, args []string) (err error) {
 			return microerror.Mask(err)
 		}
 
-		appVersion, err = getProjectVersion(dir)
+		sources, err := cmd.Flags().GetStringArray("app-version-source")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		appVersion, appVersionSource, err = getAppVersion(dir, sources)
 		if err != nil {
 			return microerror.Mask(err)
 		}
 
-		// for repositories without pkg/project/project.go
+		// for repositories where none of the sources holds the version
 		if appVersion == "" {
 			appVersion = version
 			skipAppVersionCheck = true
 		}
 	}
 
-	log.Printf("templating helm chart\ndir: %s\nsha: %s\ntag: %s\napp-version: %s\nversion: %s\n", chartDir, sha, tag, appVersion, version)
+	c := helmtemplate.Config{
+		Fs:                  fs,
+		ChartDir:            chartDir,
+		Branch:              branch,
+		Sha:                 sha,
+		Version:             version,
+		AppVersion:          appVersion,
+		AppVersionSource:    appVersionSource,
+		SkipAppVersionCheck: skipAppVersionCheck,
+		Strict:              strict,
+		LintMetadata:        lintMetadata,
+		Extra:               extra,
+		ValidateImages:      validateImages,
+		Registry:            registry,
+		ImageRepository:     imageRepository,
+		ImagePaths:          imagePaths,
+		RewriteRegistry:     rewriteRegistry,
+		ChangelogFile:       changelog,
+	}
+
+	metadata := buildMetadata{
+		Branch:     branch,
+		SHA:        sha,
+		Tag:        tag,
+		Version:    version,
+		AppVersion: appVersion,
+		Extra:      extra,
+	}
+
+	// Build metadata describes the files written, so there is nothing to
+	// export in dry-run mode.
+	export := func() error {
+		if dryRun {
+			if metaFile != "" || githubOutput || bashEnv {
+				log.Println("not exporting build metadata (dry run)")
+			}
+			return nil
+		}
+
+		return exportMetadata(metadata, metaFile, metaFormat, githubOutput, bashEnv)
+	}
+
+	if chartsRoot == "" {
+		r, err := templateChart(cmd.OutOrStdout(), base, c, tag, validate, tagBuild, dryRun, journal, output)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		metadata.add(c, r)
+
+		err = export()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
 
-	var s *helmtemplate.TemplateHelmChartTask
+	var overrides map[string]chartOverride
 	{
-		c := helmtemplate.Config{
-			Fs:                  fs,
-			ChartDir:            chartDir,
-			Branch:              branch,
-			Sha:                 sha,
-			Version:             version,
-			AppVersion:          appVersion,
-			SkipAppVersionCheck: skipAppVersionCheck,
+		overrides, err = getChartOverrides(chartsConfig)
+		if err != nil {
+			return microerror.Mask(err)
 		}
+	}
 
-		s, err = helmtemplate.NewTemplateHelmChartTask(c)
+	var charts []string
+	{
+		charts, err = helmtemplate.DiscoverCharts(base, chartsRoot)
 		if err != nil {
 			return microerror.Mask(err)
 		}
+		if len(charts) == 0 {
+			return microerror.Maskf(executionFailedError, "no %s found under %#q", helmtemplate.HelmChartYamlName, chartsRoot)
+		}
+	}
+
+	// Make sure every override refers to a discovered chart, so a typo in the
+	// config file doesn't silently build a chart with the repository version.
+	for dir := range overrides {
+		found := false
+		for _, chart := range charts {
+			rel, err := filepath.Rel(chartsRoot, chart)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			if filepath.ToSlash(rel) == dir {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return microerror.Maskf(invalidFlagError, "chart %#q configured in %#q not found under %#q", dir, chartsConfig, chartsRoot)
+		}
 	}
 
-	if err := s.Run(validate, tagBuild); err != nil {
+	// In-repo dependencies on sibling charts are pinned to the version the
+	// sibling is built with, which differs from the version of the depending
+	// chart if either of them is overridden.
+	dependencyVersions := map[string]string{}
+	for _, chart := range charts {
+		rel, err := filepath.Rel(chartsRoot, chart)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		dependencyVersions[chart] = c.Version
+		if o, ok := overrides[filepath.ToSlash(rel)]; ok && o.Version != "" {
+			dependencyVersions[chart] = o.Version
+		}
+	}
+
+	// Template every chart, continuing past failures, and report the outcome
+	// of each one at the end.
+	results := make([]chartResult, 0, len(charts))
+	for _, chart := range charts {
+		rel, err := filepath.Rel(chartsRoot, chart)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		rel = filepath.ToSlash(rel)
+
+		cc := c
+		cc.ChartDir = chart
+		cc.DependencyVersions = dependencyVersions
+		if o, ok := overrides[rel]; ok && o.Version != "" {
+			cc.Version = o.Version
+			cc.AppVersion = o.AppVersion
+			cc.AppVersionSource = chartsConfig
+			// Without an explicit appVersion the chart's own version is used
+			// and there is nothing to check it against.
+			cc.SkipAppVersionCheck = o.AppVersion == ""
+			if o.AppVersion == "" {
+				cc.AppVersion = o.Version
+			}
+		}
+
+		r, err := templateChart(cmd.OutOrStdout(), base, cc, tag, validate, tagBuild, dryRun, journal, output)
+		if err == nil {
+			metadata.add(cc, r)
+		}
+		results = append(results, chartResult{
+			Dir:     rel,
+			Version: cc.Version,
+			Err:     err,
+		})
+	}
+
+	report := cmd.OutOrStdout()
+	if dryRun && output == outputJSON {
+		report = cmd.ErrOrStderr()
+	}
+
+	err = printChartResults(report, results)
+	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	log.Println("templated helm chart")
+	var failed int
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return microerror.Maskf(executionFailedError, "%d of %d charts failed to template", failed, len(results))
+	}
+
+	err = export()
+	if err != nil {
+		return microerror.Mask(err)
+	}
 
 	return nil
 }
 
-// getProjectVersion retrieves version stored in project's Go source code. It
-// looks up the value of variable `version` in `pkg/project/project.go`. If the
-// file doesn't exist it returns an empty string.
-func getProjectVersion(repoDir string) (string, error) {
-	filePath := "pkg/project/project.go"
-	varName := "version"
+// templateChart templates a single chart configured by c. In dry-run mode c.Fs
+// is expected to be a copy-on-write layer over base and the diff between the
+// two is printed to w.
+func templateChart(w io.Writer, base afero.Fs, c helmtemplate.Config, tag string, validate, tagBuild, dryRun, journal bool, output string) (helmtemplate.Result, error) {
+	log.Printf("templating helm chart\ndir: %s\nsha: %s\ntag: %s\napp-version: %s\napp-version-source: %s\nversion: %s\n", c.ChartDir, c.Sha, tag, c.AppVersion, c.AppVersionSource, c.Version)
 
-	path := filepath.Join(repoDir, filePath)
-	path = filepath.Clean(path)
-	content, err := os.ReadFile(path)
-	if os.IsNotExist(err) {
-		return "", nil
-	} else if err != nil {
-		return "", microerror.Mask(err)
+	// Journal the original files so `helm untemplate` can revert them.
+	if journal && !dryRun {
+		var err error
+		c.JournalFile, err = helmtemplate.JournalFile(c.ChartDir)
+		if err != nil {
+			return helmtemplate.Result{}, microerror.Mask(err)
+		}
 	}
 
-	fset := token.NewFileSet()
-	node, err := parser.ParseFile(fset, "", content, 0)
+	s, err := helmtemplate.NewTemplateHelmChartTask(c)
 	if err != nil {
-		return "", microerror.Mask(err)
+		return helmtemplate.Result{}, microerror.Mask(err)
 	}
 
-	version, err := parseString(node, varName)
+	r, err := s.Run(validate, tagBuild)
 	if err != nil {
-		return "", microerror.Mask(err)
+		return helmtemplate.Result{}, microerror.Mask(err)
 	}
 
-	return version, nil
-}
+	if dryRun {
+		diffs, err := s.Diff(base)
+		if err != nil {
+			return helmtemplate.Result{}, microerror.Mask(err)
+		}
 
-// parseString returns the value of the variable varName found in r AbstractSyntaxTree.
-func parseString(r ast.Node, varName string) (value string, err error) {
-	ast.Inspect(r, func(n ast.Node) bool {
-		d, ok := n.(*ast.ValueSpec)
-		if ok {
-			for _, id := range d.Names {
-				if id.Name == varName {
-					v := id.Obj.Decl.(*ast.ValueSpec).Values[0].(*ast.BasicLit)
-					if v.Kind == token.STRING {
-						value, err = strconv.Unquote(v.Value)
-						return false
-					}
-				}
-			}
+		err = printDiffs(w, output, c.ChartDir, diffs)
+		if err != nil {
+			return helmtemplate.Result{}, microerror.Mask(err)
 		}
-		return true
-	})
 
-	if err != nil {
-		return "", microerror.Mask(err)
+		log.Println("templated helm chart (dry run, no files written)")
+		return r, nil
 	}
 
-	return value, nil
+	log.Println("templated helm chart")
+
+	return r, nil
 }
@@ -0,0 +1,13 @@
+package untemplate
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "untemplate",
+		Short: "reverts the files changed by helm template --journal, unless edited since",
+		RunE:  runUntemplateError,
+	}
+)
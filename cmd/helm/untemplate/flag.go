@@ -0,0 +1,5 @@
+package untemplate
+
+func init() {
+	Cmd.Flags().String("dir", "", "helm chart directory")
+}
@@ -0,0 +1,40 @@
+package untemplate
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/architect/v2/helmtemplate"
+)
+
+func runUntemplateError(cmd *cobra.Command, args []string) error {
+	chartDir := cmd.Flag("dir").Value.String()
+
+	if chartDir == "" {
+		return microerror.Maskf(executionFailedError, "--dir flag can't be empty")
+	}
+
+	journalFile, err := helmtemplate.JournalFile(chartDir)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	log.Printf("untemplating helm chart\ndir: %s\njournal: %s\n", chartDir, journalFile)
+
+	restored, err := helmtemplate.Untemplate(afero.NewOsFs(), chartDir, journalFile)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, file := range restored {
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "restored %s\n", file)
+	}
+
+	log.Println("untemplated helm chart")
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package valuesdiff
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "values-diff",
+		Short: "compares helm chart values between two git refs or directories and fails on breaking changes without a major version bump",
+		RunE:  runValuesDiffError,
+	}
+)
@@ -0,0 +1,12 @@
+package valuesdiff
+
+func init() {
+	Cmd.Flags().String("dir", "", "helm chart directory, used for both sides unless --from-dir or --to-dir are set")
+	Cmd.Flags().String("from-dir", "", "helm chart directory of the old version")
+	Cmd.Flags().String("from-ref", "", "git ref the old version is read at, instead of the working tree")
+	Cmd.Flags().String("from-version", "", "version of the old chart (default the version in its Chart.yaml, unknown if that is an untemplated placeholder)")
+	Cmd.Flags().String("to-dir", "", "helm chart directory of the new version")
+	Cmd.Flags().String("to-ref", "", "git ref the new version is read at, instead of the working tree")
+	Cmd.Flags().String("to-version", "", "version of the new chart (default the version in its Chart.yaml, unknown if that is an untemplated placeholder)")
+	Cmd.Flags().StringP("output", "o", "text", "output format. allowed: text,json")
+}
@@ -0,0 +1,130 @@
+package valuesdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/architect/v2/helmvaluesdiff"
+)
+
+func runValuesDiffError(cmd *cobra.Command, args []string) error {
+	var (
+		dir         = cmd.Flag("dir").Value.String()
+		fromDir     = cmd.Flag("from-dir").Value.String()
+		fromRef     = cmd.Flag("from-ref").Value.String()
+		fromVersion = cmd.Flag("from-version").Value.String()
+		toDir       = cmd.Flag("to-dir").Value.String()
+		toRef       = cmd.Flag("to-ref").Value.String()
+		toVersion   = cmd.Flag("to-version").Value.String()
+		output      = cmd.Flag("output").Value.String()
+	)
+
+	if fromDir == "" {
+		fromDir = dir
+	}
+	if toDir == "" {
+		toDir = dir
+	}
+	if fromDir == "" || toDir == "" {
+		return microerror.Maskf(executionFailedError, "--dir or both --from-dir and --to-dir must be set")
+	}
+	if output != "text" && output != "json" {
+		return microerror.Maskf(executionFailedError, "unknown output format %q", output)
+	}
+
+	var t *helmvaluesdiff.ValuesDiffTask
+	{
+		c := helmvaluesdiff.Config{
+			Fs:          afero.NewOsFs(),
+			FromDir:     fromDir,
+			FromRef:     fromRef,
+			FromVersion: fromVersion,
+			ToDir:       toDir,
+			ToRef:       toRef,
+			ToVersion:   toVersion,
+		}
+
+		var err error
+		t, err = helmvaluesdiff.NewValuesDiffTask(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	log.Printf("comparing helm chart values\n%s\n", t)
+
+	result, err := t.Run()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(result, "", "    ")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s\n", data)
+	} else {
+		printResult(cmd.OutOrStdout(), result)
+	}
+
+	if !result.Allowed() {
+		return microerror.Maskf(executionFailedError, "breaking values changes require a major version bump, got %s bump from %#q to %#q", result.Bump, result.FromVersion, result.ToVersion)
+	}
+
+	return nil
+}
+
+// printResult prints one line per change, marking breaking ones.
+func printResult(w io.Writer, r helmvaluesdiff.Result) {
+	_, _ = fmt.Fprintf(w, "%s -> %s (%s bump)\n", r.FromVersion, r.ToVersion, r.Bump)
+
+	if len(r.Changes) == 0 {
+		_, _ = fmt.Fprintf(w, "  no changes\n")
+		return
+	}
+
+	for _, c := range r.Changes {
+		marker := ""
+		if c.Breaking {
+			marker = " BREAKING"
+		}
+
+		var detail string
+		switch c.Type {
+		case helmvaluesdiff.ChangeAdded:
+			detail = describe(c.NewType, c.New)
+		case helmvaluesdiff.ChangeRemoved:
+			detail = describe(c.OldType, c.Old)
+		case helmvaluesdiff.ChangeTypeChanged:
+			detail = fmt.Sprintf("%s -> %s", c.OldType, c.NewType)
+		case helmvaluesdiff.ChangeDefaultChanged:
+			detail = fmt.Sprintf("%s -> %s", format(c.Old), format(c.New))
+		}
+
+		_, _ = fmt.Fprintf(w, "  %-16s %s %s: %s%s\n", c.Type, c.File, c.Path, detail, marker)
+	}
+}
+
+// describe returns the type of a value or, for values.yaml changes, the value
+// itself.
+func describe(typ string, value interface{}) string {
+	if value != nil {
+		return format(value)
+	}
+	return typ
+}
+
+func format(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
@@ -0,0 +1,13 @@
+package verify
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "verify",
+		Short: "renders helm chart with values.yaml and ci/*.yaml and validates resources against local JSON schemas",
+		RunE:  runVerifyError,
+	}
+)
@@ -0,0 +1,130 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/architect/v2/helmverify"
+)
+
+func runVerifyError(cmd *cobra.Command, args []string) error {
+	var (
+		chartDir             = cmd.Flag("dir").Value.String()
+		releaseName          = cmd.Flag("release-name").Value.String()
+		namespace            = cmd.Flag("namespace").Value.String()
+		kubeVersion          = cmd.Flag("kube-version").Value.String()
+		output               = cmd.Flag("output").Value.String()
+		ignoreMissingSchemas bool
+	)
+	{
+		var err error
+		ignoreMissingSchemas, err = strconv.ParseBool(cmd.Flag("ignore-missing-schemas").Value.String())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if chartDir == "" {
+		return microerror.Maskf(executionFailedError, "--dir flag can't be empty")
+	}
+	if output != "text" && output != "json" {
+		return microerror.Maskf(executionFailedError, "unknown output format %q", output)
+	}
+
+	schemaDirs, err := cmd.Flags().GetStringArray("schema-dir")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var t *helmverify.VerifyChartTask
+	{
+		c := helmverify.Config{
+			Fs:                   afero.NewOsFs(),
+			ChartDir:             chartDir,
+			SchemaDirs:           schemaDirs,
+			IgnoreMissingSchemas: ignoreMissingSchemas,
+			ReleaseName:          releaseName,
+			Namespace:            namespace,
+			KubeVersion:          kubeVersion,
+		}
+
+		t, err = helmverify.NewVerifyChartTask(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	log.Printf("verifying helm chart\n%s\n", t)
+
+	reports, err := t.Run()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(reports, "", "    ")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s\n", data)
+	} else {
+		printReports(cmd.OutOrStdout(), reports, ignoreMissingSchemas)
+	}
+
+	var failed []string
+	for _, r := range reports {
+		if r.Failed {
+			failed = append(failed, r.ValuesFile)
+		}
+	}
+	if len(failed) > 0 {
+		return microerror.Maskf(executionFailedError, "chart %#q failed verification with %s", chartDir, strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// printReports prints the outcome per values file and per resource.
+// Resources without a schema are skipped if ignoreMissingSchemas is set and
+// fail the run otherwise.
+func printReports(w io.Writer, reports []helmverify.Report, ignoreMissingSchemas bool) {
+	for _, r := range reports {
+		_, _ = fmt.Fprintf(w, "%s\n", r.ValuesFile)
+
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(w, "  ERROR rendering failed: %s\n", r.Error)
+			continue
+		}
+
+		for _, res := range r.Resources {
+			var status string
+			switch res.Status {
+			case helmverify.StatusValid:
+				status = "PASS"
+			case helmverify.StatusInvalid:
+				status = "FAIL"
+			case helmverify.StatusNoSchema:
+				status = "FAIL"
+				if ignoreMissingSchemas {
+					status = "SKIP"
+				}
+			}
+
+			_, _ = fmt.Fprintf(w, "  %-5s %s %s %s (%s)\n", status, res.APIVersion, res.Kind, res.Name, res.Template)
+			if res.Status == helmverify.StatusNoSchema {
+				_, _ = fmt.Fprintf(w, "        no schema found\n")
+			}
+			for _, v := range res.Violations {
+				_, _ = fmt.Fprintf(w, "        %s\n", v)
+			}
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package preparerelease
 
 func init() {
+	Cmd.Flags().Bool("generate-changelog", false, "if true, add entries for the conventional commits since the previous release tag to CHANGELOG.md before updating it")
 	Cmd.Flags().Bool("update-changelog", true, "if true, update CHANGELOG.md")
 	Cmd.Flags().String("version", "", "version to be released")
 }
@@ -4,6 +4,8 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/giantswarm/architect/v2/changelog"
 )
 
 // A post-prepare-release changelog: the stable "## [1.2.3]" section already
@@ -350,7 +352,7 @@ func Test_modifier_ensureReleaseCandidateChangelogsAggregated_errors(t *testing.
 	}
 }
 
-func Test_changelogDocument_rcSections(t *testing.T) {
+func Test_rcSections(t *testing.T) {
 	input := `# Changelog
 
 ## [1.2.3] - 2026-07-09
@@ -365,12 +367,12 @@ func Test_changelogDocument_rcSections(t *testing.T) {
 
 ## [1.1.0] - d
 `
-	doc := parseChangelogDocument(input)
-	got := doc.rcSections("1.2.3")
+	doc := changelog.Parse(input)
+	got := rcSections(doc, "1.2.3")
 
 	var keys []string
 	for _, s := range got {
-		keys = append(keys, s.versionKey)
+		keys = append(keys, s.Version)
 	}
 	want := []string{"1.2.3-rc.1", "1.2.3-rc.2", "1.2.3-rc.10"}
 	if strings.Join(keys, ",") != strings.Join(want, ",") {
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"path/filepath"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+
+	"github.com/giantswarm/architect/v2/changelog"
+)
+
+// CompileChangelogFragments adds the entries of the changelog fragments in
+// changelog.FragmentsDir to the section of the new version, in canonical
+// category order, and deletes the fragment files. It returns the compiled
+// fragments and is a no-op if there are none.
+//
+// It must run after AddReleaseToChangelogMd, which creates the "## [<version>]"
+// section the fragments are compiled into.
+func (m *Modifier) CompileChangelogFragments() ([]changelog.Fragment, error) {
+	fs := afero.NewOsFs()
+	dir := filepath.Join(m.workingDir, changelog.FragmentsDir)
+
+	fragments, err := changelog.ReadFragments(fs, dir)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if len(fragments) == 0 {
+		return nil, nil
+	}
+
+	err = modifyFile(filepath.Join(m.workingDir, FileChangelogMd), func(content []byte) ([]byte, error) {
+		return m.compileChangelogFragments(content, fragments)
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// Only delete the fragments once they are in the changelog.
+	for _, f := range fragments {
+		err = fs.Remove(filepath.Join(dir, f.Name))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	return fragments, nil
+}
+
+func (m *Modifier) compileChangelogFragments(content []byte, fragments []changelog.Fragment) ([]byte, error) {
+	content, err := changelog.Parse(string(content)).AddFragments(m.newVersion, fragments)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return content, nil
+}
@@ -0,0 +1,152 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/giantswarm/architect/v2/changelog"
+)
+
+// Test_modifier_CompileChangelogFragments runs the changelog steps of
+// prepare-release in order: the fragments are compiled into the new release
+// section, deleted, and then aggregated with the RC sections like the
+// Unreleased delta.
+func Test_modifier_CompileChangelogFragments(t *testing.T) {
+	input := `# Changelog
+
+## [Unreleased]
+
+### Added
+
+- Post-RC feature.
+
+## [1.2.3-rc.1] - 2026-07-06
+
+### Added
+
+- Feature A.
+
+### Fixed
+
+- Bug B.
+
+[Unreleased]: https://github.com/giantswarm/x/compare/v1.2.3-rc.1...HEAD
+[1.2.3-rc.1]: https://github.com/giantswarm/x/releases/tag/v1.2.3-rc.1
+`
+
+	fragments := map[string]string{
+		"7-fixed.md": "- Fix from fragment 7.\n",
+		"3-added.md": "Add from fragment 3.\n",
+		"README.md":  "# Changelog fragments\n\nOne file per change, named `<id>-<category>.md`.\n",
+	}
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, FileChangelogMd), []byte(input), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(filepath.Join(dir, changelog.FragmentsDir), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range fragments {
+		err = os.WriteFile(filepath.Join(dir, changelog.FragmentsDir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := NewModifier(ModifierConfig{
+		NewVersion: "1.2.3",
+		Repo:       "giantswarm/x",
+		WorkingDir: dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.AddReleaseToChangelogMd()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	compiled, err := m.CompileChangelogFragments()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = m.EnsureReleaseCandidateChangelogsAggregated()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var compiledNames []string
+	for _, f := range compiled {
+		compiledNames = append(compiledNames, f.Name)
+	}
+	if diff := cmp.Diff([]string{"3-added.md", "7-fixed.md"}, compiledNames); diff != "" {
+		t.Errorf("compiled fragments mismatch (-want +got):\n%s", diff)
+	}
+
+	// The compiled fragments are deleted, other files are left alone.
+	entries, err := os.ReadDir(filepath.Join(dir, changelog.FragmentsDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var remaining []string
+	for _, e := range entries {
+		remaining = append(remaining, e.Name())
+	}
+	sort.Strings(remaining)
+	if diff := cmp.Diff([]string{"README.md"}, remaining); diff != "" {
+		t.Errorf("remaining files mismatch (-want +got):\n%s", diff)
+	}
+
+	date := time.Now().Format("2006-01-02")
+	want := `# Changelog
+
+## [Unreleased]
+
+## [1.2.3] - ` + date + `
+
+### Added
+
+- Post-RC feature.
+- Add from fragment 3.
+- Feature A.
+
+### Changed
+
+- This release aggregates all changes from release candidate 1.2.3-rc.1.
+
+### Fixed
+
+- Fix from fragment 7.
+- Bug B.
+
+## [1.2.3-rc.1] - 2026-07-06
+
+### Added
+
+- Feature A.
+
+### Fixed
+
+- Bug B.
+
+[Unreleased]: https://github.com/giantswarm/x/compare/v1.2.3...HEAD
+[1.2.3]: https://github.com/giantswarm/x/compare/v1.2.3-rc.1...v1.2.3
+[1.2.3-rc.1]: https://github.com/giantswarm/x/releases/tag/v1.2.3-rc.1
+`
+
+	got, err := os.ReadFile(filepath.Join(dir, FileChangelogMd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("changelog mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,36 @@
+package internal
+
+import (
+	"path/filepath"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/architect/v2/changelog"
+)
+
+// GenerateChangelogEntries adds entries to the Unreleased section for the
+// conventional commits since the previous release tag, skipping the ones
+// already in the section. It returns the added entries.
+//
+// It must run before AddReleaseToChangelogMd, which turns the Unreleased
+// section into the section of the new version.
+func (m *Modifier) GenerateChangelogEntries() ([]changelog.Entry, error) {
+	_, commits, err := changelog.CommitsSinceTag(m.workingDir)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var added []changelog.Entry
+	err = modifyFile(filepath.Join(m.workingDir, FileChangelogMd), func(content []byte) ([]byte, error) {
+		content, added, err = changelog.Parse(string(content)).MergeEntries(changelog.UnreleasedVersion, changelog.ConventionalEntries(commits))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		return content, nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return added, nil
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/giantswarm/microerror"
 	"github.com/spf13/cobra"
 
+	"github.com/giantswarm/architect/v2/changelog"
 	"github.com/giantswarm/architect/v2/cmd/preparerelease/internal"
 )
 
@@ -29,6 +30,11 @@ func runPrepareRelease(cmd *cobra.Command, args []string) error {
 		return microerror.Mask(err)
 	}
 
+	generateChangelog, err := cmd.Flags().GetBool("generate-changelog")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
 	var m *internal.Modifier
 	{
 		c := internal.ModifierConfig{
@@ -43,6 +49,14 @@ func runPrepareRelease(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if updateChangelog && generateChangelog {
+		entries, err := m.GenerateChangelogEntries()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		cmd.Printf("Added %d entries generated from conventional commits to %#q.\n", len(entries), internal.FileChangelogMd)
+	}
+
 	if updateChangelog {
 		err = m.AddReleaseToChangelogMd()
 		if err != nil {
@@ -50,6 +64,14 @@ func runPrepareRelease(cmd *cobra.Command, args []string) error {
 		}
 		cmd.Printf("File %#q updated.\n", internal.FileChangelogMd)
 
+		fragments, err := m.CompileChangelogFragments()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if len(fragments) > 0 {
+			cmd.Printf("Compiled %d changelog fragment(s) from %#q into %#q and deleted them.\n", len(fragments), changelog.FragmentsDir, internal.FileChangelogMd)
+		}
+
 		// When promoting a release candidate to stable, merge the RC changelog
 		// sections into the new stable section. No-op for RC/dev targets and for
 		// stable releases without matching RC entries.
@@ -7,6 +7,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/giantswarm/architect/v2/cmd/changelog"
 	"github.com/giantswarm/architect/v2/cmd/create"
 	"github.com/giantswarm/architect/v2/cmd/helm"
 	"github.com/giantswarm/architect/v2/cmd/preparerelease"
@@ -65,6 +66,7 @@ func init() {
 	RootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", dryRun, "show what would be executed, but take no action")
 
 	RootCmd.AddCommand(cmdProject.Cmd)
+	RootCmd.AddCommand(changelog.Cmd)
 	RootCmd.AddCommand(create.Cmd)
 	RootCmd.AddCommand(helm.Cmd)
 	RootCmd.AddCommand(preparerelease.Cmd)
@@ -0,0 +1,223 @@
+// Package helmimages lists the container images deployed by a helm chart.
+package helmimages
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+
+	"github.com/giantswarm/architect/v2/helmrender"
+)
+
+// podSpecPaths maps the workload kinds, by API group and kind, to the path of
+// their pod spec.
+var podSpecPaths = map[string][]string{
+	"/Pod":             {"spec"},
+	"apps/DaemonSet":   {"spec", "template", "spec"},
+	"apps/Deployment":  {"spec", "template", "spec"},
+	"apps/ReplicaSet":  {"spec", "template", "spec"},
+	"apps/StatefulSet": {"spec", "template", "spec"},
+	"batch/CronJob":    {"spec", "jobTemplate", "spec", "template", "spec"},
+	"batch/Job":        {"spec", "template", "spec"},
+}
+
+// ListImagesTask is used to run a list-helm-chart-images command.
+type ListImagesTask struct {
+	renderer *helmrender.Renderer
+
+	chartDir string
+}
+
+// Config holds configuration for building a new ListImagesTask.
+type Config struct {
+	Fs afero.Fs
+
+	// ChartDir is the directory of the (templated) chart.
+	ChartDir string
+
+	// ReleaseName, Namespace and KubeVersion configure rendering, see
+	// helmrender.Config.
+	ReleaseName string
+	Namespace   string
+	KubeVersion string
+}
+
+// Image is a container image deployed by the chart.
+type Image struct {
+	// Reference is the image reference as written in the pod spec.
+	Reference string   `json:"reference"`
+	Sources   []Source `json:"sources"`
+}
+
+// Source is a container using an image.
+type Source struct {
+	// Template is the chart template the workload was rendered from, e.g.
+	// `my-app/templates/deployment.yaml`.
+	Template  string `json:"template"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Container string `json:"container"`
+	// Init is set for init containers.
+	Init bool `json:"init,omitempty"`
+	// ValuesFiles are the values files, relative to the chart directory, the
+	// container is rendered with.
+	ValuesFiles []string `json:"valuesFiles"`
+}
+
+func (s Source) String() string {
+	container := s.Container
+	if s.Init {
+		container = "init:" + container
+	}
+	return fmt.Sprintf("%s %s/%s %s", s.Template, s.Kind, s.Name, container)
+}
+
+// Run renders the chart with values.yaml and every ci/*.yaml values file and
+// returns the images of the containers of every workload, deduplicated and
+// sorted by reference. Rendering failures are returned as errors, as the list
+// would be incomplete.
+func (t *ListImagesTask) Run() ([]Image, error) {
+	valuesFiles, err := t.renderer.ValuesFiles()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	images := map[string]map[string]*Source{}
+	for _, valuesFile := range valuesFiles {
+		resources, err := t.renderer.Render(valuesFile)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, r := range resources {
+			for _, c := range containers(r) {
+				if images[c.image] == nil {
+					images[c.image] = map[string]*Source{}
+				}
+
+				key := c.source.String()
+				s, ok := images[c.image][key]
+				if !ok {
+					s = &c.source
+					images[c.image][key] = s
+				}
+				s.ValuesFiles = append(s.ValuesFiles, valuesFile)
+			}
+		}
+	}
+
+	result := make([]Image, 0, len(images))
+	for reference, sources := range images {
+		image := Image{Reference: reference}
+		for _, s := range sources {
+			image.Sources = append(image.Sources, *s)
+		}
+		sort.Slice(image.Sources, func(i, j int) bool {
+			return image.Sources[i].String() < image.Sources[j].String()
+		})
+		result = append(result, image)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Reference < result[j].Reference
+	})
+
+	return result, nil
+}
+
+type container struct {
+	image  string
+	source Source
+}
+
+// containers returns the containers and init containers of r, if it is a
+// workload.
+func containers(r helmrender.Resource) []container {
+	group, _, found := strings.Cut(r.APIVersion, "/")
+	if !found {
+		group = ""
+	}
+
+	specPath, ok := podSpecPaths[group+"/"+r.Kind]
+	if !ok {
+		return nil
+	}
+
+	var spec interface{} = r.Object
+	for _, key := range specPath {
+		m, ok := spec.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		spec = m[key]
+	}
+	podSpec, ok := spec.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var result []container
+	for _, field := range []string{"initContainers", "containers"} {
+		list, _ := podSpec[field].([]interface{})
+		for _, item := range list {
+			c, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			image, _ := c["image"].(string)
+			if image == "" {
+				continue
+			}
+			name, _ := c["name"].(string)
+
+			result = append(result, container{
+				image: image,
+				source: Source{
+					Template:  r.Template,
+					Kind:      r.Kind,
+					Name:      r.Name,
+					Container: name,
+					Init:      field == "initContainers",
+				},
+			})
+		}
+	}
+
+	return result
+}
+
+func (t *ListImagesTask) String() string {
+	return fmt.Sprintf("%s:\t%s", "list-helm-chart-images", t.chartDir)
+}
+
+// NewListImagesTask creates a new ListImagesTask.
+func NewListImagesTask(config Config) (*ListImagesTask, error) {
+	if config.Fs == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Fs must not be empty", config)
+	}
+
+	if config.ChartDir == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ChartDir must not be empty", config)
+	}
+
+	renderer, err := helmrender.NewRenderer(helmrender.Config{
+		Fs:          config.Fs,
+		ChartDir:    config.ChartDir,
+		ReleaseName: config.ReleaseName,
+		Namespace:   config.Namespace,
+		KubeVersion: config.KubeVersion,
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	t := &ListImagesTask{
+		renderer: renderer,
+
+		chartDir: config.ChartDir,
+	}
+
+	return t, nil
+}
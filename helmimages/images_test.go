@@ -0,0 +1,156 @@
+package helmimages
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/giantswarm/architect/v2/helmrender"
+)
+
+const workloads = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: {{ .Values.image }}
+      containers:
+      - name: app
+        image: {{ .Values.image }}
+      - name: sidecar
+        image: docker.io/library/busybox:1.36
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: my-job
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: job
+            image: docker.io/library/busybox:1.36
+---
+apiVersion: example.com/v1
+kind: Deployment
+metadata:
+  name: not-a-workload
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: ignored:1.0
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config
+data:
+  image: ignored:1.0
+`
+
+// TestListImagesTask tests listing the images of a chart rendered with every
+// values file.
+func TestListImagesTask(t *testing.T) {
+	testCases := []struct {
+		name           string
+		files          map[string]string
+		expectedImages []Image
+		errorMatcher   func(err error) bool
+	}{
+		{
+			name: "case 0: workloads with default and ci values",
+			files: map[string]string{
+				"Chart.yaml":                "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+				"values.yaml":               "image: gsoci.azurecr.io/giantswarm/my-app:1.0.0\n",
+				"ci/other-values.yaml":      "image: gsoci.azurecr.io/giantswarm/my-app:1.0.0-ci\n",
+				"templates/workloads.yaml":  workloads,
+				"templates/pod.yaml":        "{{- if .Values.pod }}\napiVersion: v1\nkind: Pod\nmetadata:\n  name: my-pod\nspec:\n  containers:\n  - name: pod\n    image: docker.io/library/busybox:1.36\n{{- end }}\n",
+				"ci/pod-values.yaml":        "pod: true\nimage: gsoci.azurecr.io/giantswarm/my-app:1.0.0\n",
+				"templates/statefulset.yml": "apiVersion: apps/v1\nkind: StatefulSet\nmetadata:\n  name: my-set\nspec:\n  template:\n    spec:\n      containers:\n      - name: db\n        image: \"\"\n",
+			},
+			expectedImages: []Image{
+				{
+					Reference: "docker.io/library/busybox:1.36",
+					Sources: []Source{
+						{Template: "my-app/templates/pod.yaml", Kind: "Pod", Name: "my-pod", Container: "pod", ValuesFiles: []string{"ci/pod-values.yaml"}},
+						{Template: "my-app/templates/workloads.yaml", Kind: "CronJob", Name: "my-job", Container: "job", ValuesFiles: []string{"values.yaml", "ci/other-values.yaml", "ci/pod-values.yaml"}},
+						{Template: "my-app/templates/workloads.yaml", Kind: "Deployment", Name: "my-app", Container: "sidecar", ValuesFiles: []string{"values.yaml", "ci/other-values.yaml", "ci/pod-values.yaml"}},
+					},
+				},
+				{
+					Reference: "gsoci.azurecr.io/giantswarm/my-app:1.0.0",
+					Sources: []Source{
+						{Template: "my-app/templates/workloads.yaml", Kind: "Deployment", Name: "my-app", Container: "app", ValuesFiles: []string{"values.yaml", "ci/pod-values.yaml"}},
+						{Template: "my-app/templates/workloads.yaml", Kind: "Deployment", Name: "my-app", Container: "init", Init: true, ValuesFiles: []string{"values.yaml", "ci/pod-values.yaml"}},
+					},
+				},
+				{
+					Reference: "gsoci.azurecr.io/giantswarm/my-app:1.0.0-ci",
+					Sources: []Source{
+						{Template: "my-app/templates/workloads.yaml", Kind: "Deployment", Name: "my-app", Container: "app", ValuesFiles: []string{"ci/other-values.yaml"}},
+						{Template: "my-app/templates/workloads.yaml", Kind: "Deployment", Name: "my-app", Container: "init", Init: true, ValuesFiles: []string{"ci/other-values.yaml"}},
+					},
+				},
+			},
+		},
+		{
+			name: "case 1: chart failing to render",
+			files: map[string]string{
+				"Chart.yaml":                "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+				"values.yaml":               "image: \"\"\n",
+				"templates/deployment.yaml": "{{ required \"image is required\" .Values.image }}\n",
+			},
+			errorMatcher: helmrender.IsRenderFailed,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			fs := afero.NewMemMapFs()
+			for name, content := range tc.files {
+				err := afero.WriteFile(fs, filepath.Join("/chart", name), []byte(content), 0600)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			task, err := NewListImagesTask(Config{
+				Fs:       fs,
+				ChartDir: "/chart",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error when creating NewListImagesTask: %v\n", err)
+			}
+
+			images, err := task.Run()
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if diff := cmp.Diff(tc.expectedImages, images); diff != "" {
+				t.Errorf("images mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
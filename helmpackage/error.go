@@ -0,0 +1,32 @@
+package helmpackage
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var invalidChartError = &microerror.Error{
+	Kind: "invalidChartError",
+}
+
+// IsInvalidChart asserts invalidChartError.
+func IsInvalidChart(err error) bool {
+	return microerror.Cause(err) == invalidChartError
+}
+
+var digestMismatchError = &microerror.Error{
+	Kind: "digestMismatchError",
+}
+
+// IsDigestMismatch asserts digestMismatchError.
+func IsDigestMismatch(err error) bool {
+	return microerror.Cause(err) == digestMismatchError
+}
@@ -0,0 +1,228 @@
+package helmpackage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+)
+
+// indexPermission is the permission of a newly written index.
+var indexPermission os.FileMode = 0644
+
+// IndexTask is used to run a helm-index command.
+type IndexTask struct {
+	fs afero.Fs
+
+	indexFile string
+	packages  []string
+	baseURL   string
+	created   time.Time
+}
+
+// IndexConfig holds configuration for building a new IndexTask.
+type IndexConfig struct {
+	Fs afero.Fs
+
+	// IndexFile is the path of the repository index. It is created if it
+	// doesn't exist.
+	IndexFile string
+	// Packages are the paths of the chart packages to add to the index.
+	Packages []string
+	// BaseURL is prepended to the package file name to form the chart URL.
+	// The bare file name is used when empty.
+	BaseURL string
+	// Created is the creation time of added entries and the generation time
+	// of the index, typically the commit time or SOURCE_DATE_EPOCH.
+	Created time.Time
+}
+
+// IndexEntry describes a chart package processed by an IndexTask.
+type IndexEntry struct {
+	Name    string
+	Version string
+	Digest  string
+	// Added is false when the index already held the same package.
+	Added bool
+}
+
+// Run merges the packages into the index and writes it. Entries already in the
+// index are preserved as they are, in their order, and new versions are
+// inserted before the first older version of their chart. A package whose name and version are
+// already indexed with the same digest is skipped, one with a different
+// digest fails the whole run without writing the index.
+func (t IndexTask) Run() ([]IndexEntry, error) {
+	index, err := t.loadIndex()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var entries []IndexEntry
+	var added bool
+	for _, p := range t.packages {
+		content, err := afero.ReadFile(t.fs, p)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		c, err := loader.LoadArchive(bytes.NewReader(content))
+		if err != nil {
+			return nil, microerror.Maskf(invalidChartError, "loading %#q: %s", p, err)
+		}
+
+		sum := sha256.Sum256(content)
+		e := IndexEntry{
+			Name:    c.Metadata.Name,
+			Version: c.Metadata.Version,
+			Digest:  hex.EncodeToString(sum[:]),
+		}
+
+		if existing, ok := indexed(index, e.Name, e.Version); ok {
+			if existing.Digest != e.Digest {
+				return nil, microerror.Maskf(digestMismatchError, "%s %s is already indexed with digest %s, refusing to replace it with %#q (digest %s)", e.Name, e.Version, existing.Digest, p, e.Digest)
+			}
+			entries = append(entries, e)
+			continue
+		}
+
+		cv := &repo.ChartVersion{
+			Metadata: c.Metadata,
+			URLs:     []string{t.url(filepath.Base(p))},
+			Created:  t.created,
+			Digest:   e.Digest,
+		}
+		index.Entries[e.Name] = insertVersion(index.Entries[e.Name], cv)
+
+		e.Added = true
+		added = true
+		entries = append(entries, e)
+	}
+
+	// Leave an up to date index untouched, so rerunning on the same packages
+	// doesn't produce a change.
+	if !added {
+		return entries, nil
+	}
+
+	index.Generated = t.created
+
+	out, err := yaml.Marshal(index)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	err = afero.WriteFile(t.fs, t.indexFile, out, indexPermission)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return entries, nil
+}
+
+// insertVersion inserts cv into versions, which Helm lists newest first,
+// before the first version older than cv, without reordering the others.
+// Versions which aren't semantic versions are never considered older.
+func insertVersion(versions repo.ChartVersions, cv *repo.ChartVersion) repo.ChartVersions {
+	i := len(versions)
+	if v, err := semver.NewVersion(cv.Version); err == nil {
+		for j, existing := range versions {
+			ev, err := semver.NewVersion(existing.Version)
+			if err == nil && ev.LessThan(v) {
+				i = j
+				break
+			}
+		}
+	}
+
+	result := make(repo.ChartVersions, 0, len(versions)+1)
+	result = append(result, versions[:i]...)
+	result = append(result, cv)
+	result = append(result, versions[i:]...)
+	return result
+}
+
+// loadIndex reads the index, or returns an empty one if it doesn't exist.
+func (t IndexTask) loadIndex() (*repo.IndexFile, error) {
+	content, err := afero.ReadFile(t.fs, t.indexFile)
+	if os.IsNotExist(err) {
+		index := repo.NewIndexFile()
+		index.Generated = t.created
+		return index, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var index repo.IndexFile
+	err = yaml.Unmarshal(content, &index)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "parsing %#q: %s", t.indexFile, err)
+	}
+	if index.APIVersion == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%#q has no apiVersion", t.indexFile)
+	}
+	if index.Entries == nil {
+		index.Entries = map[string]repo.ChartVersions{}
+	}
+
+	return &index, nil
+}
+
+func (t IndexTask) url(file string) string {
+	if t.baseURL == "" {
+		return file
+	}
+	return strings.TrimSuffix(t.baseURL, "/") + "/" + file
+}
+
+func (t IndexTask) String() string {
+	return fmt.Sprintf("%s:\t%s packages:%s url:%s created:%s", "helm-index", t.indexFile, strings.Join(t.packages, ","), t.baseURL, t.created.Format(time.RFC3339))
+}
+
+// indexed returns the entry of the given chart version, if any. Unlike
+// repo.IndexFile.Get it matches the version exactly.
+func indexed(index *repo.IndexFile, name, version string) (*repo.ChartVersion, bool) {
+	for _, cv := range index.Entries[name] {
+		if cv != nil && cv.Metadata != nil && cv.Version == version {
+			return cv, true
+		}
+	}
+	return nil, false
+}
+
+// NewIndexTask creates a new IndexTask.
+func NewIndexTask(config IndexConfig) (*IndexTask, error) {
+	if config.Fs == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Fs must not be empty", config)
+	}
+
+	if config.IndexFile == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.IndexFile must not be empty", config)
+	}
+
+	if len(config.Packages) == 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Packages must not be empty", config)
+	}
+
+	if config.Created.IsZero() {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Created must not be empty", config)
+	}
+
+	t := &IndexTask{
+		fs:        config.Fs,
+		indexFile: config.IndexFile,
+		packages:  config.Packages,
+		baseURL:   config.BaseURL,
+		created:   config.Created.UTC().Truncate(time.Second),
+	}
+
+	return t, nil
+}
@@ -0,0 +1,216 @@
+package helmpackage
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+)
+
+// TestIndexTask tests merging chart packages into a repository index.
+func TestIndexTask(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	existingIndex := `apiVersion: v1
+generated: "2025-01-01T00:00:00Z"
+entries:
+  my-app:
+  - apiVersion: v2
+    name: my-app
+    version: 2.0.0
+    created: "2025-01-01T00:00:00Z"
+    digest: "4567"
+    urls:
+    - https://example.com/my-app-2.0.0.tgz
+  - apiVersion: v2
+    name: my-app
+    version: 0.9.0
+    created: "2025-01-01T00:00:00Z"
+    digest: "0123"
+    urls:
+    - https://example.com/my-app-0.9.0.tgz
+`
+
+	// unsortedIndex lists versions out of order, which must be kept.
+	unsortedIndex := `apiVersion: v1
+generated: "2025-01-01T00:00:00Z"
+entries:
+  my-app:
+  - apiVersion: v2
+    name: my-app
+    version: 0.9.0
+    created: "2025-01-01T00:00:00Z"
+    digest: "0123"
+    urls:
+    - https://example.com/my-app-0.9.0.tgz
+  - apiVersion: v2
+    name: my-app
+    version: 2.0.0
+    created: "2025-01-01T00:00:00Z"
+    digest: "4567"
+    urls:
+    - https://example.com/my-app-2.0.0.tgz
+`
+
+	testCases := []struct {
+		name string
+		// index is the content of the existing index, if any.
+		index string
+		// packages are the versions of the chart to package and index.
+		packages         []string
+		expectedVersions []string
+		expectedAdded    []bool
+		errorMatcher     func(err error) bool
+	}{
+		{
+			name:             "case 0: create index",
+			packages:         []string{"1.0.0", "1.1.0"},
+			expectedVersions: []string{"1.1.0", "1.0.0"},
+			expectedAdded:    []bool{true, true},
+		},
+		{
+			name:             "case 1: merge into existing index",
+			index:            existingIndex,
+			packages:         []string{"1.0.0"},
+			expectedVersions: []string{"2.0.0", "1.0.0", "0.9.0"},
+			expectedAdded:    []bool{true},
+		},
+		{
+			name:             "case 2: same package indexed twice",
+			packages:         []string{"1.0.0", "1.0.0"},
+			expectedVersions: []string{"1.0.0"},
+			expectedAdded:    []bool{true, false},
+		},
+		{
+			name:         "case 3: refuse to replace version with different digest",
+			index:        existingIndex,
+			packages:     []string{"2.0.0"},
+			errorMatcher: IsDigestMismatch,
+		},
+		{
+			name:             "case 4: existing versions not reordered",
+			index:            unsortedIndex,
+			packages:         []string{"3.0.0", "1.0.0"},
+			expectedVersions: []string{"3.0.0", "1.0.0", "0.9.0", "2.0.0"},
+			expectedAdded:    []bool{true, true},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			fs := afero.NewMemMapFs()
+
+			if tc.index != "" {
+				err := afero.WriteFile(fs, "/repo/index.yaml", []byte(tc.index), 0600)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var packages []string
+			for _, version := range tc.packages {
+				chartYaml := "apiVersion: v2\nname: my-app\nversion: " + version + "\nannotations:\n  application.giantswarm.io/team: honeybadger\n"
+				err := afero.WriteFile(fs, "/chart/Chart.yaml", []byte(chartYaml), 0600)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				task, err := NewPackageChartTask(Config{
+					Fs:          fs,
+					ChartDir:    "/chart",
+					Destination: "/out",
+					ModTime:     created,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				r, err := task.Run()
+				if err != nil {
+					t.Fatal(err)
+				}
+				packages = append(packages, r.Path)
+			}
+
+			task, err := NewIndexTask(IndexConfig{
+				Fs:        fs,
+				IndexFile: "/repo/index.yaml",
+				Packages:  packages,
+				BaseURL:   "https://example.com/",
+				Created:   created,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error when creating NewIndexTask: %v\n", err)
+			}
+
+			entries, err := task.Run()
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+			if err != nil {
+				content, err := afero.ReadFile(fs, "/repo/index.yaml")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(content) != tc.index {
+					t.Fatalf("index was modified despite error")
+				}
+				return
+			}
+
+			var added []bool
+			for _, e := range entries {
+				added = append(added, e.Added)
+			}
+			if diff := cmp.Diff(tc.expectedAdded, added); diff != "" {
+				t.Errorf("added mismatch (-want +got):\n%s", diff)
+			}
+
+			content, err := afero.ReadFile(fs, "/repo/index.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var index repo.IndexFile
+			err = yaml.Unmarshal(content, &index)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var versions []string
+			for _, cv := range index.Entries["my-app"] {
+				versions = append(versions, cv.Version)
+
+				if cv.Created.Equal(created) {
+					wantURL := "https://example.com/my-app-" + cv.Version + ".tgz"
+					if diff := cmp.Diff([]string{wantURL}, cv.URLs); diff != "" {
+						t.Errorf("urls of %s mismatch (-want +got):\n%s", cv.Version, diff)
+					}
+					if cv.Annotations["application.giantswarm.io/team"] != "honeybadger" {
+						t.Errorf("annotations of %s not copied from %s", cv.Version, HelmChartYamlName)
+					}
+				} else if tc.index == "" {
+					t.Errorf("created of %s == %s, want %s", cv.Version, cv.Created, created)
+				}
+			}
+			if diff := cmp.Diff(tc.expectedVersions, versions); diff != "" {
+				t.Errorf("versions mismatch (-want +got):\n%s", diff)
+			}
+			if !index.Generated.Equal(created) {
+				t.Errorf("generated == %s, want %s", index.Generated, created)
+			}
+		})
+	}
+}
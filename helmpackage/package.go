@@ -0,0 +1,279 @@
+// Package helmpackage provides functions for packaging helm charts
+// reproducibly.
+package helmpackage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/ignore"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// HelmChartYamlName is the name of Helm's chart yaml.
+	HelmChartYamlName = "Chart.yaml"
+	// HelmIgnoreName is the name of the file listing files excluded from a
+	// chart package.
+	HelmIgnoreName = ".helmignore"
+)
+
+var (
+	// filePermission is the permission of every file in a chart package,
+	// matching `helm package`.
+	filePermission int64 = 0644
+	// packagePermission is the permission of the written chart package.
+	packagePermission os.FileMode = 0644
+)
+
+// PackageChartTask is used to run a package-helm-chart command.
+type PackageChartTask struct {
+	fs afero.Fs
+
+	chartDir    string
+	destination string
+	modTime     time.Time
+}
+
+// Config holds configuration for building a new PackageChartTask.
+type Config struct {
+	Fs afero.Fs
+
+	// ChartDir is the directory of the (templated) chart to package.
+	ChartDir string
+	// Destination is the directory the package is written to.
+	Destination string
+	// ModTime is the modification time of every file in the package,
+	// typically the commit time or SOURCE_DATE_EPOCH.
+	ModTime time.Time
+}
+
+// Result describes a written chart package.
+type Result struct {
+	// Path is the path of the written package.
+	Path string
+	// Digest is the hex encoded sha256 digest of the package.
+	Digest string
+}
+
+// Run packages the chart into <destination>/<name>-<version>.tgz. Files
+// matched by .helmignore are excluded and entries are written in lexical order
+// with fixed ownership, permissions and modification time, so the same chart
+// content always yields byte for byte the same package.
+func (t PackageChartTask) Run() (Result, error) {
+	var metadata chart.Metadata
+	{
+		content, err := afero.ReadFile(t.fs, filepath.Join(t.chartDir, HelmChartYamlName))
+		if err != nil {
+			return Result{}, microerror.Mask(err)
+		}
+		err = yaml.Unmarshal(content, &metadata)
+		if err != nil {
+			return Result{}, microerror.Mask(err)
+		}
+		if metadata.Name == "" || metadata.Version == "" {
+			return Result{}, microerror.Maskf(invalidChartError, "%s in %#q must set name and version", HelmChartYamlName, t.chartDir)
+		}
+		if path.Base(metadata.Name) != metadata.Name {
+			return Result{}, microerror.Maskf(invalidChartError, "chart name %#q must not contain path separators", metadata.Name)
+		}
+		// Like `helm package`, refuse versions which aren't semantic
+		// versions, e.g. a chart which hasn't been templated yet.
+		if _, err := semver.NewVersion(metadata.Version); err != nil {
+			return Result{}, microerror.Maskf(invalidChartError, "chart version %#q is not a semantic version, has the chart been templated?", metadata.Version)
+		}
+	}
+
+	files, err := Files(t.fs, t.chartDir)
+	if err != nil {
+		return Result{}, microerror.Mask(err)
+	}
+
+	var buf bytes.Buffer
+	{
+		// The gzip header carries no name and no modification time.
+		gw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gw)
+
+		for _, file := range files {
+			content, err := afero.ReadFile(t.fs, filepath.Join(t.chartDir, filepath.FromSlash(file)))
+			if err != nil {
+				return Result{}, microerror.Mask(err)
+			}
+
+			h := &tar.Header{
+				Name:     path.Join(metadata.Name, file),
+				Mode:     filePermission,
+				Size:     int64(len(content)),
+				ModTime:  t.modTime,
+				Typeflag: tar.TypeReg,
+				Format:   tar.FormatPAX,
+			}
+			err = tw.WriteHeader(h)
+			if err != nil {
+				return Result{}, microerror.Mask(err)
+			}
+			_, err = tw.Write(content)
+			if err != nil {
+				return Result{}, microerror.Mask(err)
+			}
+		}
+
+		err = tw.Close()
+		if err != nil {
+			return Result{}, microerror.Mask(err)
+		}
+		err = gw.Close()
+		if err != nil {
+			return Result{}, microerror.Mask(err)
+		}
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	r := Result{
+		Path:   filepath.Join(t.destination, fmt.Sprintf("%s-%s.tgz", metadata.Name, metadata.Version)),
+		Digest: hex.EncodeToString(sum[:]),
+	}
+
+	err = t.fs.MkdirAll(t.destination, 0755)
+	if err != nil {
+		return Result{}, microerror.Mask(err)
+	}
+	err = afero.WriteFile(t.fs, r.Path, buf.Bytes(), packagePermission)
+	if err != nil {
+		return Result{}, microerror.Mask(err)
+	}
+
+	return r, nil
+}
+
+// Files returns the sorted slash separated paths, relative to chartDir, of the
+// files making up the chart, i.e. the files to be packaged. It mirrors Helm's
+// directory loader: .helmignore rules plus Helm's defaults are applied,
+// ignored directories are skipped entirely, symlinks are followed and other
+// irregular files are rejected.
+func Files(fs afero.Fs, chartDir string) ([]string, error) {
+	rules := ignore.Empty()
+	{
+		content, err := afero.ReadFile(fs, filepath.Join(chartDir, HelmIgnoreName))
+		if err == nil {
+			rules, err = ignore.Parse(bytes.NewReader(content))
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, microerror.Mask(err)
+		}
+		rules.AddDefaults()
+	}
+
+	root, err := fs.Stat(chartDir)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var files []string
+	err = walkFiles(fs, chartDir, "", rules, []os.FileInfo{root}, &files)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// walkFiles adds the files in dir, whose slash separated path relative to the
+// chart directory is rel, to files. Symlinks are resolved and the files or
+// directories they point to are packaged under the name of the symlink.
+// parents are the directories being walked, used to detect symlink loops.
+func walkFiles(fs afero.Fs, dir, rel string, rules *ignore.Rules, parents []os.FileInfo, files *[]string) error {
+	entries, err := afero.ReadDir(fs, dir)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, info := range entries {
+		p := filepath.Join(dir, info.Name())
+		r := path.Join(rel, info.Name())
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			info, err = fs.Stat(p)
+			if err != nil {
+				return microerror.Maskf(invalidChartError, "cannot package symlink %#q: %s", r, err)
+			}
+		}
+
+		if info.IsDir() {
+			if rules.Ignore(r, info) {
+				continue
+			}
+			for _, parent := range parents {
+				if os.SameFile(parent, info) {
+					return microerror.Maskf(invalidChartError, "cannot package symlink loop at %#q", r)
+				}
+			}
+
+			err = walkFiles(fs, p, r, rules, append(parents, info), files)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			continue
+		}
+		if rules.Ignore(r, info) {
+			continue
+		}
+		if !info.Mode().IsRegular() {
+			return microerror.Maskf(invalidChartError, "cannot package irregular file %#q", r)
+		}
+
+		*files = append(*files, r)
+	}
+
+	return nil
+}
+
+func (t PackageChartTask) String() string {
+	return fmt.Sprintf("%s:\t%s destination:%s modTime:%s", "package-helm-chart", t.chartDir, t.destination, t.modTime.Format(time.RFC3339))
+}
+
+// NewPackageChartTask creates a new PackageChartTask.
+func NewPackageChartTask(config Config) (*PackageChartTask, error) {
+	if config.Fs == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Fs must not be empty", config)
+	}
+
+	if config.ChartDir == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ChartDir must not be empty", config)
+	}
+
+	if config.Destination == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Destination must not be empty", config)
+	}
+
+	if config.ModTime.IsZero() {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ModTime must not be empty", config)
+	}
+
+	t := &PackageChartTask{
+		fs:          config.Fs,
+		chartDir:    config.ChartDir,
+		destination: config.Destination,
+		modTime:     config.ModTime.UTC().Truncate(time.Second),
+	}
+
+	return t, nil
+}
@@ -0,0 +1,242 @@
+package helmpackage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+// TestPackageChartTask tests packaging is reproducible, honours .helmignore
+// and yields a package Helm can load.
+func TestPackageChartTask(t *testing.T) {
+	modTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		name            string
+		files           map[string]string
+		expectedPath    string
+		expectedEntries []string
+		errorMatcher    func(err error) bool
+	}{
+		{
+			name: "case 0: package chart honouring .helmignore",
+			files: map[string]string{
+				"Chart.yaml":                "apiVersion: v2\nname: my-app\nversion: 1.2.3\n",
+				"values.yaml":               "replicas: 1\n",
+				".helmignore":               "*.bak\nci/\n",
+				"templates/deployment.yaml": "kind: Deployment\n",
+				"templates/.hidden":         "ignored by helm defaults\n",
+				"templates/old.yaml.bak":    "ignored\n",
+				"ci/test-values.yaml":       "ignored\n",
+				"charts/sub/Chart.yaml":     "apiVersion: v2\nname: sub\nversion: 0.1.0\n",
+			},
+			expectedPath: "/out/my-app-1.2.3.tgz",
+			expectedEntries: []string{
+				"my-app/.helmignore",
+				"my-app/Chart.yaml",
+				"my-app/charts/sub/Chart.yaml",
+				"my-app/templates/deployment.yaml",
+				"my-app/values.yaml",
+			},
+		},
+		{
+			name: "case 1: missing version",
+			files: map[string]string{
+				"Chart.yaml": "apiVersion: v2\nname: my-app\n",
+			},
+			errorMatcher: IsInvalidChart,
+		},
+		{
+			name: "case 2: chart not templated",
+			files: map[string]string{
+				"Chart.yaml": "apiVersion: v2\nname: my-app\nversion: \"[[ .Version ]]\"\n",
+			},
+			errorMatcher: IsInvalidChart,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			// Package the same chart twice, with files created in different
+			// order and at different times, to make sure output is stable.
+			var packages [][]byte
+			var result Result
+			for run := 0; run < 2; run++ {
+				fs := afero.NewMemMapFs()
+				names := make([]string, 0, len(tc.files))
+				for name := range tc.files {
+					names = append(names, name)
+				}
+				if run == 1 {
+					for l, r := 0, len(names)-1; l < r; l, r = l+1, r-1 {
+						names[l], names[r] = names[r], names[l]
+					}
+				}
+				for _, name := range names {
+					err := afero.WriteFile(fs, filepath.Join("/chart", name), []byte(tc.files[name]), 0600)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				task, err := NewPackageChartTask(Config{
+					Fs:          fs,
+					ChartDir:    "/chart",
+					Destination: "/out",
+					ModTime:     modTime,
+				})
+				if err != nil {
+					t.Fatalf("unexpected error when creating NewPackageChartTask: %v\n", err)
+				}
+
+				result, err = task.Run()
+
+				switch {
+				case err == nil && tc.errorMatcher == nil:
+					// correct; carry on
+				case err != nil && tc.errorMatcher == nil:
+					t.Fatalf("error == %#v, want nil", err)
+				case err == nil && tc.errorMatcher != nil:
+					t.Fatalf("error == nil, want non-nil")
+				case !tc.errorMatcher(err):
+					t.Fatalf("error == %#v, want matching", err)
+				}
+				if err != nil {
+					return
+				}
+
+				data, err := afero.ReadFile(fs, result.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				packages = append(packages, data)
+			}
+
+			if !bytes.Equal(packages[0], packages[1]) {
+				t.Fatalf("packages differ between runs")
+			}
+			if result.Path != tc.expectedPath {
+				t.Errorf("path == %#q, want %#q", result.Path, tc.expectedPath)
+			}
+
+			gr, err := gzip.NewReader(bytes.NewReader(packages[0]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tr := tar.NewReader(gr)
+			var entries []string
+			for {
+				h, err := tr.Next()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				if !h.ModTime.Equal(modTime) {
+					t.Errorf("entry %#q has modification time %s, want %s", h.Name, h.ModTime, modTime)
+				}
+				entries = append(entries, h.Name)
+			}
+			if diff := cmp.Diff(tc.expectedEntries, entries); diff != "" {
+				t.Errorf("entries mismatch (-want +got):\n%s", diff)
+			}
+
+			c, err := loader.LoadArchive(bytes.NewReader(packages[0]))
+			if err != nil {
+				t.Fatalf("helm can't load package: %v", err)
+			}
+			if c.Metadata.Version != "1.2.3" {
+				t.Errorf("loaded chart version == %#q, want %#q", c.Metadata.Version, "1.2.3")
+			}
+		})
+	}
+}
+
+// TestFilesSymlinks tests symlinked files and directories are packaged with
+// the content they point to, like Helm's loader does, and symlink loops are
+// rejected.
+func TestFilesSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	chartDir := filepath.Join(dir, "chart")
+
+	files := map[string]string{
+		"chart/Chart.yaml":            "apiVersion: v2\nname: my-app\nversion: 1.2.3\n",
+		"chart/templates/a.yaml":      "a: 1\n",
+		"shared/configmap.yaml":       "shared: true\n",
+		"shared/dashboards/main.json": "{}\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(p), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(p, []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := os.Symlink("../../shared/configmap.yaml", filepath.Join(chartDir, "templates", "configmap.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Symlink("../shared/dashboards", filepath.Join(chartDir, "dashboards"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := afero.NewOsFs()
+
+	result, err := Files(fs, chartDir)
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+	expected := []string{"Chart.yaml", "dashboards/main.json", "templates/a.yaml", "templates/configmap.yaml"}
+	if diff := cmp.Diff(expected, result); diff != "" {
+		t.Errorf("files mismatch (-want +got):\n%s", diff)
+	}
+
+	task, err := NewPackageChartTask(Config{
+		Fs:          fs,
+		ChartDir:    chartDir,
+		Destination: filepath.Join(dir, "out"),
+		ModTime:     time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := task.Run()
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+	c, err := loader.Load(r.Path)
+	if err != nil {
+		t.Fatalf("helm can't load package: %v", err)
+	}
+	for _, f := range c.Templates {
+		if f.Name == "templates/configmap.yaml" && string(f.Data) != "shared: true\n" {
+			t.Errorf("templates/configmap.yaml == %q, want the content of the symlink target", f.Data)
+		}
+	}
+
+	err = os.Symlink("..", filepath.Join(chartDir, "templates", "loop"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Files(fs, chartDir)
+	if !IsInvalidChart(err) {
+		t.Fatalf("error == %#v, want matching", err)
+	}
+}
@@ -0,0 +1,44 @@
+package helmpackage
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/go-git/go-git/v5"
+)
+
+// SourceDateEpochEnv is the environment variable defined by
+// https://reproducible-builds.org/specs/source-date-epoch/.
+const SourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+// SourceDate returns the time chart packages and index entries are stamped
+// with: SOURCE_DATE_EPOCH if set, the committer time of HEAD of the git
+// repository containing workingDir otherwise.
+func SourceDate(workingDir string) (time.Time, error) {
+	if epoch := os.Getenv(SourceDateEpochEnv); epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return time.Time{}, microerror.Maskf(invalidConfigError, "%s must be a unix timestamp, got %#q", SourceDateEpochEnv, epoch)
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	repo, err := git.PlainOpenWithOptions(workingDir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return time.Time{}, microerror.Maskf(invalidConfigError, "%s not set and no git repository found: %s", SourceDateEpochEnv, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return time.Time{}, microerror.Mask(err)
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return time.Time{}, microerror.Mask(err)
+	}
+
+	return commit.Committer.When.UTC(), nil
+}
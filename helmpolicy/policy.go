@@ -0,0 +1,449 @@
+// Package helmpolicy evaluates Rego policies against the resources rendered
+// from a helm chart, in-process with the OPA Go library.
+package helmpolicy
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/spf13/afero"
+
+	"github.com/giantswarm/architect/v2/helmrender"
+)
+
+// Levels of policy rules.
+const (
+	LevelDeny = "deny"
+	LevelWarn = "warn"
+)
+
+// Rego versions policies are parsed with.
+const (
+	// RegoV0 is the syntax of OPA < 1.0, e.g. `deny[msg] { ... }`. Modules
+	// importing `rego.v1` may use the v1 syntax. It is the default, as it is
+	// the syntax of existing conftest policies.
+	RegoV0 = "v0"
+	RegoV1 = "v1"
+)
+
+// Rules are evaluated by their name, following the conftest conventions:
+// `deny`, `violation`, `deny_<name>` and `violation_<name>` rules are
+// denials and `warn` and `warn_<name>` rules are warnings.
+var (
+	denyRuleRegexp = regexp.MustCompile(`^(deny|violation)(_[a-zA-Z0-9]+)*$`)
+	warnRuleRegexp = regexp.MustCompile(`^warn(_[a-zA-Z0-9]+)*$`)
+)
+
+// defaultPoliciesDir is the directory of the embedded default policies.
+const defaultPoliciesDir = "policy"
+
+//go:embed policy/*.rego
+var defaultPolicies embed.FS
+
+// CheckChartTask is used to run a check-helm-chart-policies command.
+type CheckChartTask struct {
+	renderer *helmrender.Renderer
+
+	chartDir   string
+	policyDirs []string
+	failOnWarn bool
+
+	// queries are the prepared queries of every deny and warn rule, by
+	// level.
+	queries map[string][]query
+}
+
+// query is the prepared query of the rules of one name of a package.
+type query struct {
+	policy string
+	rule   string
+	query  rego.PreparedEvalQuery
+}
+
+// Config holds configuration for building a new CheckChartTask.
+type Config struct {
+	Fs afero.Fs
+
+	// ChartDir is the directory of the (templated) chart to check.
+	ChartDir string
+	// PolicyDirs are the directories Rego policies are loaded from,
+	// recursively. Files ending in `_test.rego` are skipped.
+	PolicyDirs []string
+	// NoDefaultPolicies disables the embedded default policies.
+	NoDefaultPolicies bool
+	// FailOnWarn makes warnings fail the check.
+	FailOnWarn bool
+	// RegoVersion is the Rego version policies are parsed with, RegoV0 or
+	// RegoV1. It defaults to RegoV0.
+	RegoVersion string
+
+	// ReleaseName, Namespace and KubeVersion configure rendering, see
+	// helmrender.Config.
+	ReleaseName string
+	Namespace   string
+	KubeVersion string
+}
+
+// Report is the outcome of checking the chart rendered with one values file.
+type Report struct {
+	// ValuesFile is the values file, relative to the chart directory.
+	ValuesFile string `json:"valuesFile"`
+	// Failed is set if the chart couldn't be rendered or any resource
+	// violates a deny rule or, with FailOnWarn, a warn rule.
+	Failed bool `json:"failed"`
+	// Error is set if the chart couldn't be rendered.
+	Error     string           `json:"error,omitempty"`
+	Resources []ResourceReport `json:"resources"`
+}
+
+// ResourceReport is the outcome of checking a single rendered resource.
+type ResourceReport struct {
+	Template   string    `json:"template"`
+	APIVersion string    `json:"apiVersion"`
+	Kind       string    `json:"kind"`
+	Name       string    `json:"name"`
+	Failures   []Finding `json:"failures"`
+	Warnings   []Finding `json:"warnings"`
+}
+
+// Finding is a message of a violated rule.
+type Finding struct {
+	// Policy is the package of the rule, e.g. `architect.images`.
+	Policy  string `json:"policy"`
+	Message string `json:"message"`
+}
+
+// Run renders the chart with values.yaml and every ci/*.yaml values file and
+// evaluates the deny and warn rules of every policy with each rendered
+// resource as input. A values file the chart fails to render with is reported
+// without stopping the check. The returned error is only set for failures
+// unrelated to the chart.
+func (t *CheckChartTask) Run() ([]Report, error) {
+	ctx := context.Background()
+
+	valuesFiles, err := t.renderer.ValuesFiles()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	reports := make([]Report, 0, len(valuesFiles))
+	for _, valuesFile := range valuesFiles {
+		report := Report{
+			ValuesFile: valuesFile,
+			Resources:  []ResourceReport{},
+		}
+
+		resources, err := t.renderer.Render(valuesFile)
+		if helmrender.IsRenderFailed(err) {
+			report.Failed = true
+			report.Error = err.Error()
+			reports = append(reports, report)
+			continue
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, r := range resources {
+			rr := ResourceReport{
+				Template:   r.Template,
+				APIVersion: r.APIVersion,
+				Kind:       r.Kind,
+				Name:       r.Name,
+			}
+
+			rr.Failures, err = t.eval(ctx, LevelDeny, r.Object)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			rr.Warnings, err = t.eval(ctx, LevelWarn, r.Object)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			if len(rr.Failures) > 0 || (t.failOnWarn && len(rr.Warnings) > 0) {
+				report.Failed = true
+			}
+			report.Resources = append(report.Resources, rr)
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// eval evaluates the rules of level with input and returns their messages,
+// sorted by policy.
+func (t *CheckChartTask) eval(ctx context.Context, level string, input map[string]interface{}) ([]Finding, error) {
+	findings := []Finding{}
+	for _, q := range t.queries[level] {
+		rs, err := q.query.Eval(ctx, rego.EvalInput(input))
+		if err != nil {
+			return nil, microerror.Maskf(invalidPolicyError, "evaluating %s.%s: %s", q.policy, q.rule, err)
+		}
+
+		var messages []string
+		for _, r := range rs {
+			for _, e := range r.Expressions {
+				values, ok := e.Value.([]interface{})
+				if !ok {
+					return nil, microerror.Maskf(invalidPolicyError, "%s.%s must be a set, got %T", q.policy, q.rule, e.Value)
+				}
+				for _, v := range values {
+					msg, err := message(q, v)
+					if err != nil {
+						return nil, microerror.Mask(err)
+					}
+					messages = append(messages, msg)
+				}
+			}
+		}
+		sort.Strings(messages)
+
+		for _, msg := range messages {
+			findings = append(findings, Finding{Policy: q.policy, Message: msg})
+		}
+	}
+
+	return findings, nil
+}
+
+// message returns the message of a value of the rule of q, which is either a
+// string or, as supported by conftest, an object with a `msg` string.
+func message(q query, v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case map[string]interface{}:
+		if msg, ok := v["msg"].(string); ok {
+			return msg, nil
+		}
+	}
+
+	return "", microerror.Maskf(invalidPolicyError, "%s.%s: rule values must be strings or objects with a msg string, got %v", q.policy, q.rule, v)
+}
+
+func loadDefaultModules() (map[string]string, error) {
+	modules := map[string]string{}
+
+	entries, err := defaultPolicies.ReadDir(defaultPoliciesDir)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	for _, e := range entries {
+		name := path.Join(defaultPoliciesDir, e.Name())
+		data, err := defaultPolicies.ReadFile(name)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		modules["architect/"+name] = string(data)
+	}
+
+	return modules, nil
+}
+
+// loadModules adds the policies found under dir to modules.
+func loadModules(afs afero.Fs, dir string, modules map[string]string) error {
+	exists, err := afero.DirExists(afs, dir)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if !exists {
+		return microerror.Maskf(invalidConfigError, "policy directory %#q does not exist", dir)
+	}
+
+	err = afero.Walk(afs, dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if info.IsDir() || filepath.Ext(p) != ".rego" || strings.HasSuffix(p, "_test.rego") {
+			return nil
+		}
+
+		data, err := afero.ReadFile(afs, p)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		modules[p] = string(data)
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func compile(modules map[string]string, regoVersion ast.RegoVersion) (*ast.Compiler, error) {
+	compiler, err := ast.CompileModulesWithOpt(modules, ast.CompileOpts{
+		ParserOptions: ast.ParserOptions{RegoVersion: regoVersion},
+	})
+	if err != nil {
+		return nil, microerror.Maskf(invalidPolicyError, "%s", err)
+	}
+
+	return compiler, nil
+}
+
+// ruleLevel returns the level of the rule named name, or an empty string if
+// the rule isn't evaluated.
+func ruleLevel(name string) string {
+	switch {
+	case denyRuleRegexp.MatchString(name):
+		return LevelDeny
+	case warnRuleRegexp.MatchString(name):
+		return LevelWarn
+	}
+	return ""
+}
+
+// policyPackage is a package defining rules of any level.
+type policyPackage struct {
+	name string
+	path ast.Ref
+	// rules are the names of the evaluated rules of the package, by level,
+	// sorted.
+	rules map[string][]string
+}
+
+// packages returns the packages of the compiled modules which define deny
+// or warn rules, sorted by name.
+func packages(compiler *ast.Compiler) []policyPackage {
+	byName := map[string]*policyPackage{}
+	seen := map[string]bool{}
+	for _, m := range compiler.Modules {
+		for _, r := range m.Rules {
+			rule := r.Head.Ref()[0].Value.String()
+			level := ruleLevel(rule)
+			if level == "" {
+				continue
+			}
+
+			// Package paths start with `data`.
+			name := strings.TrimPrefix(m.Package.Path.String(), "data.")
+			if seen[name+"."+rule] {
+				continue
+			}
+			seen[name+"."+rule] = true
+
+			p, ok := byName[name]
+			if !ok {
+				p = &policyPackage{name: name, path: m.Package.Path, rules: map[string][]string{}}
+				byName[name] = p
+			}
+			p.rules[level] = append(p.rules[level], rule)
+		}
+	}
+	for _, p := range byName {
+		for _, rules := range p.rules {
+			sort.Strings(rules)
+		}
+	}
+
+	result := make([]policyPackage, 0, len(byName))
+	for _, p := range byName {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+
+	return result
+}
+
+func (t *CheckChartTask) String() string {
+	return fmt.Sprintf("%s:\t%s policy-dirs:%s", "check-helm-chart-policies", t.chartDir, strings.Join(t.policyDirs, ","))
+}
+
+// NewCheckChartTask creates a new CheckChartTask.
+func NewCheckChartTask(config Config) (*CheckChartTask, error) {
+	if config.Fs == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Fs must not be empty", config)
+	}
+
+	if config.ChartDir == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ChartDir must not be empty", config)
+	}
+
+	if len(config.PolicyDirs) == 0 && config.NoDefaultPolicies {
+		return nil, microerror.Maskf(invalidConfigError, "%T.PolicyDirs must not be empty when %T.NoDefaultPolicies is set", config, config)
+	}
+
+	var regoVersion ast.RegoVersion
+	switch config.RegoVersion {
+	case "", RegoV0:
+		regoVersion = ast.RegoV0
+	case RegoV1:
+		regoVersion = ast.RegoV1
+	default:
+		return nil, microerror.Maskf(invalidConfigError, "%T.RegoVersion must be %#q or %#q, got %#q", config, RegoV0, RegoV1, config.RegoVersion)
+	}
+
+	modules := map[string]string{}
+	if !config.NoDefaultPolicies {
+		var err error
+		modules, err = loadDefaultModules()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+	for _, dir := range config.PolicyDirs {
+		err := loadModules(config.Fs, dir, modules)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	compiler, err := compile(modules, regoVersion)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	queries := map[string][]query{}
+	for _, p := range packages(compiler) {
+		for _, level := range []string{LevelDeny, LevelWarn} {
+			for _, rule := range p.rules[level] {
+				q, err := rego.New(
+					rego.Compiler(compiler),
+					rego.Query(p.path.Append(ast.StringTerm(rule)).String()),
+				).PrepareForEval(context.Background())
+				if err != nil {
+					return nil, microerror.Maskf(invalidPolicyError, "preparing %s.%s: %s", p.name, rule, err)
+				}
+				queries[level] = append(queries[level], query{policy: p.name, rule: rule, query: q})
+			}
+		}
+	}
+
+	renderer, err := helmrender.NewRenderer(helmrender.Config{
+		Fs:          config.Fs,
+		ChartDir:    config.ChartDir,
+		ReleaseName: config.ReleaseName,
+		Namespace:   config.Namespace,
+		KubeVersion: config.KubeVersion,
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	t := &CheckChartTask{
+		renderer: renderer,
+
+		chartDir:   config.ChartDir,
+		policyDirs: config.PolicyDirs,
+		failOnWarn: config.FailOnWarn,
+
+		queries: queries,
+	}
+
+	return t, nil
+}
@@ -0,0 +1,30 @@
+# Images must be pinned to a tag other than latest, or a digest.
+package architect.images
+
+import rego.v1
+
+import data.architect.lib.kubernetes
+
+deny contains msg if {
+	some c in kubernetes.containers
+	not contains(c.image, "@")
+	tag := image_tag(c.image)
+	tag == "latest"
+	msg := sprintf("%s: %s %q must not use the latest tag: %s", [kubernetes.name, c.type, c.name, c.image])
+}
+
+deny contains msg if {
+	some c in kubernetes.containers
+	not contains(c.image, "@")
+	not image_tag(c.image)
+	msg := sprintf("%s: %s %q must pin its image to a tag or digest: %s", [kubernetes.name, c.type, c.name, c.image])
+}
+
+# image_tag is the tag of an image reference, which is undefined if there is
+# none. A colon in the first path segment is the registry port.
+image_tag(image) := tag if {
+	parts := split(image, "/")
+	last := parts[count(parts) - 1]
+	contains(last, ":")
+	tag := split(last, ":")[1]
+}
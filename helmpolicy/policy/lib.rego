@@ -0,0 +1,25 @@
+# Helpers shared by the default policies. Every policy is evaluated with a
+# single rendered resource as input.
+package architect.lib.kubernetes
+
+import rego.v1
+
+workload_kinds := {"DaemonSet", "Deployment", "Job", "ReplicaSet", "StatefulSet"}
+
+name := sprintf("%s/%s", [input.kind, input.metadata.name])
+
+pod_spec := input.spec if input.kind == "Pod"
+
+pod_spec := input.spec.template.spec if input.kind in workload_kinds
+
+pod_spec := input.spec.jobTemplate.spec.template.spec if input.kind == "CronJob"
+
+# containers are the containers and init containers of the pod spec, with
+# type set to "container" or "init container".
+containers contains object.union(c, {"type": "container"}) if {
+	some c in pod_spec.containers
+}
+
+containers contains object.union(c, {"type": "init container"}) if {
+	some c in pod_spec.initContainers
+}
@@ -0,0 +1,78 @@
+# Pods should satisfy the restricted Pod Security Standard, see
+# https://kubernetes.io/docs/concepts/security/pod-security-standards/#restricted.
+package architect.pss
+
+import rego.v1
+
+import data.architect.lib.kubernetes
+
+allowed_volume_types := {
+	"configMap", "csi", "downwardAPI", "emptyDir", "ephemeral",
+	"persistentVolumeClaim", "projected", "secret",
+}
+
+warn contains msg if {
+	some field in ["hostNetwork", "hostPID", "hostIPC"]
+	kubernetes.pod_spec[field] == true
+	msg := sprintf("%s: pod must not set %s", [kubernetes.name, field])
+}
+
+warn contains msg if {
+	some v in kubernetes.pod_spec.volumes
+	some t, _ in object.remove(v, ["name"])
+	not t in allowed_volume_types
+	msg := sprintf("%s: volume %q must not be of type %s", [kubernetes.name, v.name, t])
+}
+
+warn contains msg if {
+	some c in kubernetes.containers
+	c.securityContext.privileged == true
+	msg := sprintf("%s: %s %q must not be privileged", [kubernetes.name, c.type, c.name])
+}
+
+warn contains msg if {
+	some c in kubernetes.containers
+	not c.securityContext.allowPrivilegeEscalation == false
+	msg := sprintf("%s: %s %q must set securityContext.allowPrivilegeEscalation to false", [kubernetes.name, c.type, c.name])
+}
+
+warn contains msg if {
+	some c in kubernetes.containers
+	not run_as_non_root(c)
+	msg := sprintf("%s: %s %q must run as non-root", [kubernetes.name, c.type, c.name])
+}
+
+warn contains msg if {
+	some c in kubernetes.containers
+	not "ALL" in object.get(c, ["securityContext", "capabilities", "drop"], [])
+	msg := sprintf("%s: %s %q must drop ALL capabilities", [kubernetes.name, c.type, c.name])
+}
+
+warn contains msg if {
+	some c in kubernetes.containers
+	some capability in object.get(c, ["securityContext", "capabilities", "add"], [])
+	capability != "NET_BIND_SERVICE"
+	msg := sprintf("%s: %s %q must not add capability %s", [kubernetes.name, c.type, c.name, capability])
+}
+
+warn contains msg if {
+	some c in kubernetes.containers
+	not allowed_seccomp_profile(c)
+	msg := sprintf("%s: %s %q must set seccompProfile.type to RuntimeDefault or Localhost", [kubernetes.name, c.type, c.name])
+}
+
+# Container settings take precedence over pod settings.
+run_as_non_root(c) if c.securityContext.runAsNonRoot == true
+
+run_as_non_root(c) if {
+	not c.securityContext.runAsNonRoot == false
+	kubernetes.pod_spec.securityContext.runAsNonRoot == true
+}
+
+allowed_seccomp_profile(c) if seccomp_profile(c) in {"RuntimeDefault", "Localhost"}
+
+seccomp_profile(c) := c.securityContext.seccompProfile.type
+
+seccomp_profile(c) := kubernetes.pod_spec.securityContext.seccompProfile.type if {
+	not c.securityContext.seccompProfile.type
+}
@@ -0,0 +1,18 @@
+# Containers must set a memory limit and should set a CPU limit.
+package architect.resources
+
+import rego.v1
+
+import data.architect.lib.kubernetes
+
+deny contains msg if {
+	some c in kubernetes.containers
+	not c.resources.limits.memory
+	msg := sprintf("%s: %s %q must set resources.limits.memory", [kubernetes.name, c.type, c.name])
+}
+
+warn contains msg if {
+	some c in kubernetes.containers
+	not c.resources.limits.cpu
+	msg := sprintf("%s: %s %q should set resources.limits.cpu", [kubernetes.name, c.type, c.name])
+}
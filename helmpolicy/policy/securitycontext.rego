@@ -0,0 +1,18 @@
+# Pods and their containers must set a securityContext.
+package architect.securitycontext
+
+import rego.v1
+
+import data.architect.lib.kubernetes
+
+deny contains msg if {
+	kubernetes.pod_spec
+	not kubernetes.pod_spec.securityContext
+	msg := sprintf("%s: pod must set securityContext", [kubernetes.name])
+}
+
+deny contains msg if {
+	some c in kubernetes.containers
+	not c.securityContext
+	msg := sprintf("%s: %s %q must set securityContext", [kubernetes.name, c.type, c.name])
+}
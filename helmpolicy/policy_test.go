@@ -0,0 +1,308 @@
+package helmpolicy
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+const compliantDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+spec:
+  template:
+    spec:
+      securityContext:
+        runAsNonRoot: true
+        seccompProfile:
+          type: RuntimeDefault
+      containers:
+      - name: app
+        image: {{ .Values.image }}
+        resources:
+          limits:
+            cpu: 100m
+            memory: 128Mi
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop: [ALL]
+`
+
+const nonCompliantCronJob = `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: my-job
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          hostNetwork: true
+          initContainers:
+          - name: init
+            image: gsoci.azurecr.io/giantswarm/init
+            resources:
+              limits:
+                memory: 64Mi
+          containers:
+          - name: job
+            image: gsoci.azurecr.io:443/giantswarm/job:latest
+            securityContext:
+              runAsNonRoot: true
+              allowPrivilegeEscalation: false
+              seccompProfile:
+                type: RuntimeDefault
+              capabilities:
+                drop: [ALL]
+                add: [NET_ADMIN]
+`
+
+// TestCheckChartTask tests evaluating policies against rendered resources.
+func TestCheckChartTask(t *testing.T) {
+	testCases := []struct {
+		name              string
+		files             map[string]string
+		policyDirs        []string
+		noDefaultPolicies bool
+		failOnWarn        bool
+		regoVersion       string
+		expectedReports   []Report
+		errorMatcher      func(err error) bool
+	}{
+		{
+			name: "case 0: default policies",
+			files: map[string]string{
+				"chart/Chart.yaml":                "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+				"chart/values.yaml":               "image: gsoci.azurecr.io/giantswarm/my-app:1.0.0\n",
+				"chart/ci/latest-values.yaml":     "image: gsoci.azurecr.io/giantswarm/my-app:latest\n",
+				"chart/templates/deployment.yaml": compliantDeployment,
+				"chart/templates/cronjob.yaml":    nonCompliantCronJob,
+				"chart/templates/configmap.yaml":  "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: my-config\n",
+			},
+			expectedReports: []Report{
+				{
+					ValuesFile: "values.yaml",
+					Failed:     true,
+					Resources: []ResourceReport{
+						{Template: "my-app/templates/configmap.yaml", APIVersion: "v1", Kind: "ConfigMap", Name: "my-config", Failures: []Finding{}, Warnings: []Finding{}},
+						{
+							Template: "my-app/templates/cronjob.yaml", APIVersion: "batch/v1", Kind: "CronJob", Name: "my-job",
+							Failures: []Finding{
+								{Policy: "architect.images", Message: `CronJob/my-job: container "job" must not use the latest tag: gsoci.azurecr.io:443/giantswarm/job:latest`},
+								{Policy: "architect.images", Message: `CronJob/my-job: init container "init" must pin its image to a tag or digest: gsoci.azurecr.io/giantswarm/init`},
+								{Policy: "architect.resources", Message: `CronJob/my-job: container "job" must set resources.limits.memory`},
+								{Policy: "architect.securitycontext", Message: `CronJob/my-job: init container "init" must set securityContext`},
+								{Policy: "architect.securitycontext", Message: `CronJob/my-job: pod must set securityContext`},
+							},
+							Warnings: []Finding{
+								{Policy: "architect.pss", Message: `CronJob/my-job: container "job" must not add capability NET_ADMIN`},
+								{Policy: "architect.pss", Message: `CronJob/my-job: init container "init" must drop ALL capabilities`},
+								{Policy: "architect.pss", Message: `CronJob/my-job: init container "init" must run as non-root`},
+								{Policy: "architect.pss", Message: `CronJob/my-job: init container "init" must set seccompProfile.type to RuntimeDefault or Localhost`},
+								{Policy: "architect.pss", Message: `CronJob/my-job: init container "init" must set securityContext.allowPrivilegeEscalation to false`},
+								{Policy: "architect.pss", Message: `CronJob/my-job: pod must not set hostNetwork`},
+								{Policy: "architect.resources", Message: `CronJob/my-job: container "job" should set resources.limits.cpu`},
+								{Policy: "architect.resources", Message: `CronJob/my-job: init container "init" should set resources.limits.cpu`},
+							},
+						},
+						{Template: "my-app/templates/deployment.yaml", APIVersion: "apps/v1", Kind: "Deployment", Name: "my-app", Failures: []Finding{}, Warnings: []Finding{}},
+					},
+				},
+				{
+					ValuesFile: "ci/latest-values.yaml",
+					Failed:     true,
+					Resources: []ResourceReport{
+						{Template: "my-app/templates/configmap.yaml", APIVersion: "v1", Kind: "ConfigMap", Name: "my-config", Failures: []Finding{}, Warnings: []Finding{}},
+						{
+							Template: "my-app/templates/cronjob.yaml", APIVersion: "batch/v1", Kind: "CronJob", Name: "my-job",
+							Failures: []Finding{
+								{Policy: "architect.images", Message: `CronJob/my-job: container "job" must not use the latest tag: gsoci.azurecr.io:443/giantswarm/job:latest`},
+								{Policy: "architect.images", Message: `CronJob/my-job: init container "init" must pin its image to a tag or digest: gsoci.azurecr.io/giantswarm/init`},
+								{Policy: "architect.resources", Message: `CronJob/my-job: container "job" must set resources.limits.memory`},
+								{Policy: "architect.securitycontext", Message: `CronJob/my-job: init container "init" must set securityContext`},
+								{Policy: "architect.securitycontext", Message: `CronJob/my-job: pod must set securityContext`},
+							},
+							Warnings: []Finding{
+								{Policy: "architect.pss", Message: `CronJob/my-job: container "job" must not add capability NET_ADMIN`},
+								{Policy: "architect.pss", Message: `CronJob/my-job: init container "init" must drop ALL capabilities`},
+								{Policy: "architect.pss", Message: `CronJob/my-job: init container "init" must run as non-root`},
+								{Policy: "architect.pss", Message: `CronJob/my-job: init container "init" must set seccompProfile.type to RuntimeDefault or Localhost`},
+								{Policy: "architect.pss", Message: `CronJob/my-job: init container "init" must set securityContext.allowPrivilegeEscalation to false`},
+								{Policy: "architect.pss", Message: `CronJob/my-job: pod must not set hostNetwork`},
+								{Policy: "architect.resources", Message: `CronJob/my-job: container "job" should set resources.limits.cpu`},
+								{Policy: "architect.resources", Message: `CronJob/my-job: init container "init" should set resources.limits.cpu`},
+							},
+						},
+						{
+							Template: "my-app/templates/deployment.yaml", APIVersion: "apps/v1", Kind: "Deployment", Name: "my-app",
+							Failures: []Finding{
+								{Policy: "architect.images", Message: `Deployment/my-app: container "app" must not use the latest tag: gsoci.azurecr.io/giantswarm/my-app:latest`},
+							},
+							Warnings: []Finding{},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "case 1: custom policies only, warnings failing",
+			files: map[string]string{
+				"chart/Chart.yaml":               "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+				"chart/values.yaml":              "{}\n",
+				"chart/templates/configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: my-config\n",
+				"policies/labels.rego":           "package main\n\nimport rego.v1\n\nwarn contains {\"msg\": msg} if {\n\tnot input.metadata.labels\n\tmsg := sprintf(\"%s has no labels\", [input.metadata.name])\n}\n",
+				"policies/labels_test.rego":      "package main\n\nthis is not rego\n",
+			},
+			policyDirs:        []string{"/policies"},
+			noDefaultPolicies: true,
+			failOnWarn:        true,
+			expectedReports: []Report{
+				{
+					ValuesFile: "values.yaml",
+					Failed:     true,
+					Resources: []ResourceReport{
+						{
+							Template: "my-app/templates/configmap.yaml", APIVersion: "v1", Kind: "ConfigMap", Name: "my-config",
+							Failures: []Finding{},
+							Warnings: []Finding{{Policy: "main", Message: "my-config has no labels"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "case 2: invalid policy",
+			files: map[string]string{
+				"chart/Chart.yaml":     "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+				"chart/values.yaml":    "{}\n",
+				"policies/broken.rego": "package main\n\ndeny contains msg if {\n",
+			},
+			policyDirs:   []string{"/policies"},
+			errorMatcher: IsInvalidPolicy,
+		},
+		{
+			name: "case 3: conftest policies in v0 syntax with prefixed rule names",
+			files: map[string]string{
+				"chart/Chart.yaml":               "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+				"chart/values.yaml":              "{}\n",
+				"chart/templates/configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: my-config\n",
+				"policies/main.rego": `package main
+
+deny[msg] {
+	input.kind == "ConfigMap"
+	msg := sprintf("%s is a ConfigMap", [input.metadata.name])
+}
+
+deny_labels[msg] {
+	not input.metadata.labels
+	msg := sprintf("%s has no labels", [input.metadata.name])
+}
+
+violation[{"msg": msg}] {
+	not input.metadata.namespace
+	msg := sprintf("%s has no namespace", [input.metadata.name])
+}
+
+warn_annotations[msg] {
+	not input.metadata.annotations
+	msg := sprintf("%s has no annotations", [input.metadata.name])
+}
+
+denied_helper[msg] {
+	msg := "not a rule conftest evaluates"
+}
+`,
+			},
+			policyDirs:        []string{"/policies"},
+			noDefaultPolicies: true,
+			expectedReports: []Report{
+				{
+					ValuesFile: "values.yaml",
+					Failed:     true,
+					Resources: []ResourceReport{
+						{
+							Template: "my-app/templates/configmap.yaml", APIVersion: "v1", Kind: "ConfigMap", Name: "my-config",
+							Failures: []Finding{
+								{Policy: "main", Message: "my-config is a ConfigMap"},
+								{Policy: "main", Message: "my-config has no labels"},
+								{Policy: "main", Message: "my-config has no namespace"},
+							},
+							Warnings: []Finding{{Policy: "main", Message: "my-config has no annotations"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "case 4: v0 syntax parsed as v1",
+			files: map[string]string{
+				"chart/Chart.yaml":   "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+				"chart/values.yaml":  "{}\n",
+				"policies/main.rego": "package main\n\ndeny[msg] {\n\tmsg := \"denied\"\n}\n",
+			},
+			policyDirs:   []string{"/policies"},
+			regoVersion:  RegoV1,
+			errorMatcher: IsInvalidPolicy,
+		},
+		{
+			name: "case 5: unknown rego version",
+			files: map[string]string{
+				"chart/Chart.yaml":  "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+				"chart/values.yaml": "{}\n",
+			},
+			regoVersion:  "v2",
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			fs := afero.NewMemMapFs()
+			for name, content := range tc.files {
+				err := afero.WriteFile(fs, filepath.Join("/", name), []byte(content), 0600)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			task, err := NewCheckChartTask(Config{
+				Fs:                fs,
+				ChartDir:          "/chart",
+				PolicyDirs:        tc.policyDirs,
+				NoDefaultPolicies: tc.noDefaultPolicies,
+				FailOnWarn:        tc.failOnWarn,
+				RegoVersion:       tc.regoVersion,
+			})
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+			if err != nil {
+				return
+			}
+
+			reports, err := task.Run()
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+
+			if diff := cmp.Diff(tc.expectedReports, reports); diff != "" {
+				t.Errorf("reports mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package helmrender
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var renderFailedError = &microerror.Error{
+	Kind: "renderFailedError",
+}
+
+// IsRenderFailed asserts renderFailedError.
+func IsRenderFailed(err error) bool {
+	return microerror.Cause(err) == renderFailedError
+}
@@ -0,0 +1,271 @@
+// Package helmrender renders helm charts into Kubernetes resources offline,
+// using the Helm SDK the same way `helm template` does.
+package helmrender
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"sigs.k8s.io/yaml"
+
+	"github.com/giantswarm/architect/v2/helmpackage"
+)
+
+const (
+	// HelmValuesYamlName is the name of Helm's default values file.
+	HelmValuesYamlName = "values.yaml"
+	// CIValuesDirectoryName is the directory of the values files used by
+	// chart testing, which are rendered in addition to the default values.
+	CIValuesDirectoryName = "ci"
+
+	// DefaultReleaseName is the release name used when none is configured,
+	// matching `helm template`.
+	DefaultReleaseName = "release-name"
+	// DefaultNamespace is the namespace used when none is configured.
+	DefaultNamespace = "default"
+)
+
+// Resource is a single Kubernetes resource rendered from a chart.
+type Resource struct {
+	// Template is the chart template the resource was rendered from, e.g.
+	// `my-app/templates/deployment.yaml`.
+	Template   string
+	APIVersion string
+	Kind       string
+	Name       string
+	Namespace  string
+	// Object is the resource as decoded from its rendered YAML.
+	Object map[string]interface{}
+}
+
+// Renderer renders a chart with different values files.
+type Renderer struct {
+	fs afero.Fs
+
+	chartDir     string
+	releaseName  string
+	namespace    string
+	capabilities *chartutil.Capabilities
+}
+
+// Config holds configuration for building a new Renderer.
+type Config struct {
+	Fs afero.Fs
+
+	// ChartDir is the directory of the (templated) chart to render.
+	ChartDir string
+	// ReleaseName is the name of the release. Defaults to
+	// DefaultReleaseName.
+	ReleaseName string
+	// Namespace is the namespace of the release. Defaults to
+	// DefaultNamespace.
+	Namespace string
+	// KubeVersion is the Kubernetes version charts are rendered for, e.g.
+	// `1.31.0`. Defaults to the version of the Helm SDK.
+	KubeVersion string
+}
+
+// ValuesFiles returns values.yaml followed by the values files under ci/ in
+// lexical order, relative to the chart directory.
+func (r Renderer) ValuesFiles() ([]string, error) {
+	files := []string{HelmValuesYamlName}
+
+	dir := filepath.Join(r.chartDir, CIValuesDirectoryName)
+	exists, err := afero.DirExists(r.fs, dir)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if !exists {
+		return files, nil
+	}
+
+	infos, err := afero.ReadDir(r.fs, dir)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var ci []string
+	for _, info := range infos {
+		ext := filepath.Ext(info.Name())
+		if info.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		ci = append(ci, path.Join(CIValuesDirectoryName, info.Name()))
+	}
+	sort.Strings(ci)
+
+	return append(files, ci...), nil
+}
+
+// Render renders the chart with valuesFile, relative to the chart directory,
+// on top of the chart's default values. Rendering values.yaml renders the
+// chart with its default values only. Resources are returned in the order of
+// their templates, then their order within each template. CRDs from the
+// crds/ directories of the chart and its subcharts are included.
+func (r Renderer) Render(valuesFile string) ([]Resource, error) {
+	// Processing dependencies modifies the chart, so it is loaded again for
+	// every values file.
+	c, err := r.loadChart()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	values := map[string]interface{}{}
+	if valuesFile != HelmValuesYamlName {
+		data, err := afero.ReadFile(r.fs, filepath.Join(r.chartDir, filepath.FromSlash(valuesFile)))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		values, err = chartutil.ReadValues(data)
+		if err != nil {
+			return nil, microerror.Maskf(renderFailedError, "parsing %#q: %s", valuesFile, err)
+		}
+	}
+
+	err = chartutil.ProcessDependenciesWithMerge(c, values)
+	if err != nil {
+		return nil, microerror.Maskf(renderFailedError, "%s", err)
+	}
+
+	options := chartutil.ReleaseOptions{
+		Name:      r.releaseName,
+		Namespace: r.namespace,
+		Revision:  1,
+		IsInstall: true,
+	}
+	renderValues, err := chartutil.ToRenderValues(c, values, options, r.capabilities)
+	if err != nil {
+		return nil, microerror.Maskf(renderFailedError, "%s", err)
+	}
+
+	files, err := engine.Render(c, renderValues)
+	if err != nil {
+		return nil, microerror.Maskf(renderFailedError, "%s", err)
+	}
+	for _, crd := range c.CRDObjects() {
+		files[crd.Filename] = string(crd.File.Data)
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		// Partials and notes don't produce resources.
+		if strings.HasPrefix(path.Base(name), "_") || strings.HasSuffix(name, "NOTES.txt") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var resources []Resource
+	for _, name := range names {
+		docs := releaseutil.SplitManifests(files[name])
+		keys := make([]string, 0, len(docs))
+		for k := range docs {
+			keys = append(keys, k)
+		}
+		sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+		for _, k := range keys {
+			var object map[string]interface{}
+			err := yaml.Unmarshal([]byte(docs[k]), &object)
+			if err != nil {
+				return nil, microerror.Maskf(renderFailedError, "%s: %s", name, err)
+			}
+			// Documents with comments only.
+			if object == nil {
+				continue
+			}
+
+			resources = append(resources, newResource(name, object))
+		}
+	}
+
+	return resources, nil
+}
+
+// loadChart loads the chart, with the same files Helm would package.
+func (r Renderer) loadChart() (*chart.Chart, error) {
+	names, err := helmpackage.Files(r.fs, r.chartDir)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	files := make([]*loader.BufferedFile, 0, len(names))
+	for _, name := range names {
+		data, err := afero.ReadFile(r.fs, filepath.Join(r.chartDir, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		files = append(files, &loader.BufferedFile{Name: name, Data: data})
+	}
+
+	c, err := loader.LoadFiles(files)
+	if err != nil {
+		return nil, microerror.Maskf(renderFailedError, "loading chart %#q: %s", r.chartDir, err)
+	}
+
+	return c, nil
+}
+
+func newResource(template string, object map[string]interface{}) Resource {
+	r := Resource{
+		Template: template,
+		Object:   object,
+	}
+
+	r.APIVersion, _ = object["apiVersion"].(string)
+	r.Kind, _ = object["kind"].(string)
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		r.Name, _ = metadata["name"].(string)
+		r.Namespace, _ = metadata["namespace"].(string)
+	}
+
+	return r
+}
+
+// NewRenderer creates a new Renderer.
+func NewRenderer(config Config) (*Renderer, error) {
+	if config.Fs == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Fs must not be empty", config)
+	}
+
+	if config.ChartDir == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ChartDir must not be empty", config)
+	}
+
+	if config.ReleaseName == "" {
+		config.ReleaseName = DefaultReleaseName
+	}
+
+	if config.Namespace == "" {
+		config.Namespace = DefaultNamespace
+	}
+
+	capabilities := chartutil.DefaultCapabilities.Copy()
+	if config.KubeVersion != "" {
+		kubeVersion, err := chartutil.ParseKubeVersion(config.KubeVersion)
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "%T.KubeVersion %#q: %s", config, config.KubeVersion, err)
+		}
+		capabilities.KubeVersion = *kubeVersion
+	}
+
+	r := &Renderer{
+		fs:           config.Fs,
+		chartDir:     config.ChartDir,
+		releaseName:  config.ReleaseName,
+		namespace:    config.Namespace,
+		capabilities: capabilities,
+	}
+
+	return r, nil
+}
@@ -0,0 +1,151 @@
+package helmrender
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+// TestRenderer tests rendering a chart with its default and ci values.
+func TestRenderer(t *testing.T) {
+	files := map[string]string{
+		"Chart.yaml":                "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+		"values.yaml":               "replicas: 1\n",
+		"ci/b-values.yaml":          "replicas: 3\n",
+		"ci/a-values.yml":           "replicas: 2\n",
+		"ci/README.md":              "not values\n",
+		"templates/_helpers.tpl":    "{{- define \"name\" -}}{{ .Release.Name }}-{{ .Chart.Name }}{{- end -}}\n",
+		"templates/NOTES.txt":       "Installed {{ include \"name\" . }}.\n",
+		"templates/deployment.yaml": "# comment only\n---\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: {{ include \"name\" . }}\n  namespace: {{ .Release.Namespace }}\nspec:\n  replicas: {{ .Values.replicas }}\n",
+		"templates/service.yaml":    "{{- if gt (int .Values.replicas) 2 }}\napiVersion: v1\nkind: Service\nmetadata:\n  name: {{ include \"name\" . }}\n{{- end }}\n",
+		"crds/crd.yaml":             "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: apps.example.com\n",
+	}
+
+	testCases := []struct {
+		name              string
+		valuesFile        string
+		expectedResources []string
+		expectedReplicas  float64
+		errorMatcher      func(err error) bool
+	}{
+		{
+			name:       "case 0: default values",
+			valuesFile: "values.yaml",
+			expectedResources: []string{
+				"my-app/crds/crd.yaml CustomResourceDefinition apps.example.com",
+				"my-app/templates/deployment.yaml Deployment my-release-my-app",
+			},
+			expectedReplicas: 1,
+		},
+		{
+			name:       "case 1: ci values",
+			valuesFile: "ci/b-values.yaml",
+			expectedResources: []string{
+				"my-app/crds/crd.yaml CustomResourceDefinition apps.example.com",
+				"my-app/templates/deployment.yaml Deployment my-release-my-app",
+				"my-app/templates/service.yaml Service my-release-my-app",
+			},
+			expectedReplicas: 3,
+		},
+		{
+			name:         "case 2: missing values file",
+			valuesFile:   "ci/missing-values.yaml",
+			errorMatcher: func(err error) bool { return err != nil },
+		},
+	}
+
+	fs := afero.NewMemMapFs()
+	for name, content := range files {
+		err := afero.WriteFile(fs, filepath.Join("/chart", name), []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := NewRenderer(Config{
+		Fs:          fs,
+		ChartDir:    "/chart",
+		ReleaseName: "my-release",
+		Namespace:   "my-namespace",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error when creating NewRenderer: %v\n", err)
+	}
+
+	valuesFiles, err := r.ValuesFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"values.yaml", "ci/a-values.yml", "ci/b-values.yaml"}, valuesFiles); diff != "" {
+		t.Errorf("values files mismatch (-want +got):\n%s", diff)
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			resources, err := r.Render(tc.valuesFile)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+			if err != nil {
+				return
+			}
+
+			var got []string
+			for _, res := range resources {
+				got = append(got, res.Template+" "+res.Kind+" "+res.Name)
+
+				if res.Kind == "Deployment" {
+					if res.Namespace != "my-namespace" {
+						t.Errorf("namespace == %#q, want %#q", res.Namespace, "my-namespace")
+					}
+					replicas := res.Object["spec"].(map[string]interface{})["replicas"]
+					if replicas != tc.expectedReplicas {
+						t.Errorf("replicas == %v, want %v", replicas, tc.expectedReplicas)
+					}
+				}
+			}
+			if diff := cmp.Diff(tc.expectedResources, got); diff != "" {
+				t.Errorf("resources mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestRendererInvalidValues tests rendering errors are reported as such.
+func TestRendererInvalidValues(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"Chart.yaml":                "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+		"values.yaml":               "replicas: 1\n",
+		"templates/deployment.yaml": "{{ required \"name is required\" .Values.name }}\n",
+	}
+	for name, content := range files {
+		err := afero.WriteFile(fs, filepath.Join("/chart", name), []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := NewRenderer(Config{Fs: fs, ChartDir: "/chart"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.Render("values.yaml")
+	if !IsRenderFailed(err) {
+		t.Fatalf("error == %#v, want matching", err)
+	}
+}
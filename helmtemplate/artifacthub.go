@@ -0,0 +1,191 @@
+package helmtemplate
+
+import (
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/giantswarm/architect/v2/changelog"
+)
+
+// ArtifactHubChangesAnnotation is the Chart.yaml annotation Artifact Hub
+// shows release notes from, see
+// https://artifacthub.io/docs/topics/annotations/helm/.
+const ArtifactHubChangesAnnotation = "artifacthub.io/changes"
+
+// artifactHubChange is a single entry of the artifacthub.io/changes
+// annotation.
+type artifactHubChange struct {
+	Kind        string            `yaml:"kind"`
+	Description string            `yaml:"description"`
+	Links       []artifactHubLink `yaml:"links,omitempty"`
+}
+
+type artifactHubLink struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+// artifactHubChanges returns the changes of version from the changelog. The
+// section of the version is required for tagged builds. Other builds fall
+// back to the unreleased section, as their version isn't released yet. A
+// nil result means there are no changes to annotate.
+func (t TemplateHelmChartTask) artifactHubChanges(version string, tagBuild bool) ([]artifactHubChange, error) {
+	content, err := afero.ReadFile(t.fs, t.changelogFile)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	doc := changelog.Parse(string(content))
+	section, ok := doc.Section(version)
+	if !ok && !tagBuild {
+		section, ok = doc.Section(changelog.UnreleasedVersion)
+	}
+	if !ok {
+		return nil, microerror.Maskf(validationFailedError, "%#q has no section for version %#q", t.changelogFile, version)
+	}
+
+	var changes []artifactHubChange
+	for _, e := range doc.Entries(section) {
+		// Artifact Hub kinds are the Keep a Changelog categories. Entries
+		// outside of them, e.g. "Dependency updates", are changes as well.
+		kind := "changed"
+		if changelog.IsCanonicalCategory(string(e.Category)) {
+			kind = strings.ToLower(string(e.Category))
+		}
+
+		change := artifactHubChange{
+			Kind:        kind,
+			Description: changelog.StripLinks(e.Text),
+		}
+		for _, l := range changelog.Links(e.Text) {
+			// Artifact Hub only renders absolute links.
+			if !strings.HasPrefix(l.URL, "https://") && !strings.HasPrefix(l.URL, "http://") {
+				continue
+			}
+			name := l.Text
+			if name == "" {
+				name = l.URL
+			}
+			change.Links = append(change.Links, artifactHubLink{Name: name, URL: l.URL})
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// setArtifactHubChanges sets the artifacthub.io/changes annotation of the
+// Chart.yaml document data to changes, replacing any previous value. Only the
+// lines of the annotation are written, so the formatting and comments of the
+// rest of the document are kept intact.
+func setArtifactHubChanges(data []byte, changes []artifactHubChange) ([]byte, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, microerror.Maskf(validationFailedError, "%s must be a mapping", HelmChartYamlName)
+	}
+	chart := doc.Content[0]
+
+	value, err := yaml.Marshal(changes)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	lines := strings.Split(string(data), "\n")
+
+	key, annotations := mappingEntry(chart, "annotations")
+	switch {
+	case key == nil:
+		// Add the annotations before trailing blank lines.
+		i := len(lines)
+		for i > 0 && strings.TrimSpace(lines[i-1]) == "" {
+			i--
+		}
+		indent := 0
+		if len(chart.Content) > 0 {
+			indent = chart.Content[0].Column - 1
+		}
+		added := append([]string{strings.Repeat(" ", indent) + "annotations:"}, annotationLines(indent+2, value)...)
+		lines = spliceLines(lines, i, i, added)
+
+	case annotations.Kind == yaml.MappingNode && annotations.Style&yaml.FlowStyle == 0 && len(annotations.Content) > 0:
+		indent := annotations.Content[0].Column - 1
+		if k, _ := mappingEntry(annotations, ArtifactHubChangesAnnotation); k != nil {
+			start := k.Line - 1
+			lines = spliceLines(lines, start, blockEnd(lines, start, k.Column-1), annotationLines(indent, value))
+		} else {
+			end := blockEnd(lines, key.Line-1, key.Column-1)
+			lines = spliceLines(lines, end, end, annotationLines(indent, value))
+		}
+
+	case annotations.Tag == "!!null" || (annotations.Kind == yaml.MappingNode && len(annotations.Content) == 0):
+		// `annotations:` without a value or `annotations: {}`.
+		start := key.Line - 1
+		indent := key.Column - 1
+		added := append([]string{strings.Repeat(" ", indent) + "annotations:"}, annotationLines(indent+2, value)...)
+		lines = spliceLines(lines, start, start+1, added)
+
+	default:
+		return nil, microerror.Maskf(validationFailedError, "can't set %#q annotation: annotations of %s must be a block mapping", ArtifactHubChangesAnnotation, HelmChartYamlName)
+	}
+
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// annotationLines returns the lines of the artifacthub.io/changes annotation
+// with value as a literal block, indented by indent spaces.
+func annotationLines(indent int, value []byte) []string {
+	prefix := strings.Repeat(" ", indent)
+	lines := []string{prefix + ArtifactHubChangesAnnotation + ": |"}
+	for _, line := range strings.Split(strings.TrimSuffix(string(value), "\n"), "\n") {
+		if line != "" {
+			line = prefix + "  " + line
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// blockEnd returns the index of the line after the block of the key at line
+// start indented by indent spaces, i.e. the key line and the more indented
+// lines following it. Trailing blank lines aren't part of the block.
+func blockEnd(lines []string, start, indent int) int {
+	end := start + 1
+	for i := start + 1; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(line)-len(strings.TrimLeft(line, " ")) <= indent {
+			break
+		}
+		end = i + 1
+	}
+	return end
+}
+
+// spliceLines replaces lines[start:end] with added.
+func spliceLines(lines []string, start, end int, added []string) []string {
+	result := make([]string, 0, len(lines)-(end-start)+len(added))
+	result = append(result, lines[:start]...)
+	result = append(result, added...)
+	result = append(result, lines[end:]...)
+	return result
+}
+
+// mappingEntry returns the key and value nodes of key in the mapping node m,
+// or nils.
+func mappingEntry(m *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i], m.Content[i+1]
+		}
+	}
+	return nil, nil
+}
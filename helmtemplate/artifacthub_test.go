@@ -0,0 +1,239 @@
+package helmtemplate
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+const testChangelog = `# Changelog
+
+## [Unreleased]
+
+### Fixed
+
+- Unreleased fix.
+
+## [1.2.3] - 2026-10-01
+
+### Added
+
+- Add ` + "`foo`" + ` option, see [#12](https://github.com/giantswarm/my-app/pull/12).
+
+### Security
+
+- Bump dependencies fixing
+  [CVE-2026-1234](https://nvd.nist.gov/vuln/detail/CVE-2026-1234).
+
+## [1.2.2] - 2026-09-01
+
+Free text.
+
+- Dependency updates
+
+### Internal
+
+- Refactor templates.
+
+[Unreleased]: https://github.com/giantswarm/my-app/compare/v1.2.3...HEAD
+[1.2.3]: https://github.com/giantswarm/my-app/compare/v1.2.2...v1.2.3
+`
+
+// TestArtifactHubChanges tests the artifacthub.io/changes annotation of
+// Chart.yaml is generated from the changelog.
+func TestArtifactHubChanges(t *testing.T) {
+	testCases := []struct {
+		name          string
+		chart         string
+		version       string
+		tagBuild      bool
+		expectedChart string
+		errorMatcher  func(err error) bool
+	}{
+		{
+			name:     "case 0: released version",
+			chart:    "name: my-app # the name\nversion: [[ .Version ]]\nannotations:\n  io.giantswarm.application.team: \"honeybadger\"\n",
+			version:  "1.2.3",
+			tagBuild: true,
+			expectedChart: `name: my-app # the name
+version: 1.2.3
+annotations:
+  io.giantswarm.application.team: "honeybadger"
+  artifacthub.io/changes: |
+    - kind: added
+      description: 'Add ` + "`foo`" + ` option, see #12.'
+      links:
+        - name: '#12'
+          url: https://github.com/giantswarm/my-app/pull/12
+    - kind: security
+      description: Bump dependencies fixing CVE-2026-1234.
+      links:
+        - name: CVE-2026-1234
+          url: https://nvd.nist.gov/vuln/detail/CVE-2026-1234
+`,
+		},
+		{
+			name:     "case 1: development build of unreleased version replacing annotation",
+			chart:    "name: my-app\nversion: [[ .Version ]]\nannotations:\n  artifacthub.io/changes: |\n    - old\n",
+			version:  "1.2.4-ea82e754178bb2b8065aca0a0760e77ce3733649",
+			tagBuild: false,
+			expectedChart: `name: my-app
+version: 1.2.4-ea82e754178bb2b8065aca0a0760e77ce3733649
+annotations:
+  artifacthub.io/changes: |
+    - kind: fixed
+      description: Unreleased fix.
+`,
+		},
+		{
+			name:          "case 2: chart without annotations",
+			chart:         "name: my-app\nversion: [[ .Version ]]\n",
+			version:       "1.2.3",
+			tagBuild:      true,
+			expectedChart: "name: my-app\nversion: 1.2.3\nannotations:\n  artifacthub.io/changes: |\n    - kind: added\n      description: 'Add `foo` option, see #12.'\n      links:\n        - name: '#12'\n          url: https://github.com/giantswarm/my-app/pull/12\n    - kind: security\n      description: Bump dependencies fixing CVE-2026-1234.\n      links:\n        - name: CVE-2026-1234\n          url: https://nvd.nist.gov/vuln/detail/CVE-2026-1234\n",
+		},
+		{
+			name:         "case 3: missing section for tagged build",
+			chart:        "name: my-app\nversion: [[ .Version ]]\n",
+			version:      "1.2.4",
+			tagBuild:     true,
+			errorMatcher: IsValidationFailedError,
+		},
+		{
+			name:     "case 4: entries outside a Keep a Changelog category",
+			chart:    "name: my-app\nversion: [[ .Version ]]\n",
+			version:  "1.2.2",
+			tagBuild: true,
+			expectedChart: `name: my-app
+version: 1.2.2
+annotations:
+  artifacthub.io/changes: |
+    - kind: changed
+      description: Free text.
+    - kind: changed
+      description: Dependency updates
+    - kind: changed
+      description: Refactor templates.
+`,
+		},
+		{
+			name: "case 5: formatting of the rest of Chart.yaml kept",
+			chart: `# Chart of my-app.
+apiVersion: "v2"
+name: 'my-app'
+version: [[ .Version ]]
+keywords: [app, "giant swarm"]
+annotations:
+    # Team owning the app.
+    io.giantswarm.application.team: honeybadger
+    artifacthub.io/changes: |
+        - kind: removed
+          description: Old change.
+
+    config.giantswarm.io/version: 1.x.x   # trailing comment
+restrictions:
+    clusterSingleton: true
+
+`,
+			version:  "1.2.3",
+			tagBuild: true,
+			expectedChart: `# Chart of my-app.
+apiVersion: "v2"
+name: 'my-app'
+version: 1.2.3
+keywords: [app, "giant swarm"]
+annotations:
+    # Team owning the app.
+    io.giantswarm.application.team: honeybadger
+    artifacthub.io/changes: |
+      - kind: added
+        description: 'Add ` + "`foo`" + ` option, see #12.'
+        links:
+          - name: '#12'
+            url: https://github.com/giantswarm/my-app/pull/12
+      - kind: security
+        description: Bump dependencies fixing CVE-2026-1234.
+        links:
+          - name: CVE-2026-1234
+            url: https://nvd.nist.gov/vuln/detail/CVE-2026-1234
+
+    config.giantswarm.io/version: 1.x.x   # trailing comment
+restrictions:
+    clusterSingleton: true
+
+`,
+		},
+		{
+			name:     "case 6: empty annotations",
+			chart:    "name: my-app\nversion: [[ .Version ]]\nannotations: {}\nhome: https://github.com/giantswarm/my-app\n",
+			version:  "1.2.4-ea82e754178bb2b8065aca0a0760e77ce3733649",
+			tagBuild: false,
+			expectedChart: `name: my-app
+version: 1.2.4-ea82e754178bb2b8065aca0a0760e77ce3733649
+annotations:
+  artifacthub.io/changes: |
+    - kind: fixed
+      description: Unreleased fix.
+home: https://github.com/giantswarm/my-app
+`,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			config := Config{
+				Fs:            afero.NewMemMapFs(),
+				ChartDir:      "/chart",
+				Branch:        "master",
+				Sha:           "ea82e754178bb2b8065aca0a0760e77ce3733649",
+				Version:       tc.version,
+				ChangelogFile: "/CHANGELOG.md",
+			}
+
+			err := setup(config, map[string]string{
+				HelmChartYamlName:  tc.chart,
+				HelmValuesYamlName: "replicas: 1\n",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error during setup: %v\n", err)
+			}
+			err = afero.WriteFile(config.Fs, config.ChangelogFile, []byte(testChangelog), permission)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			task, err := NewTemplateHelmChartTask(config)
+			if err != nil {
+				t.Fatalf("unexpected error when creating NewTemplateHelmChartTask: %v\n", err)
+			}
+
+			_, err = task.Run(false, tc.tagBuild)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+			if err != nil {
+				return
+			}
+
+			chart, err := afero.ReadFile(config.Fs, "/chart/"+HelmChartYamlName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expectedChart, string(chart)); diff != "" {
+				t.Errorf("Chart.yaml mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
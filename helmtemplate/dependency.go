@@ -0,0 +1,181 @@
+package helmtemplate
+
+import (
+	"bytes"
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/giantswarm/microerror"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/provenance"
+	"sigs.k8s.io/yaml"
+)
+
+// localRepositoryPrefix is the repository prefix of dependencies pointing at
+// charts in the same repository, e.g. `file://../my-app-crds`.
+const localRepositoryPrefix = "file://"
+
+// validateDependencies makes sure the version constraint of every in-repo
+// dependency listed in the rendered Chart.yaml at file allows the version the
+// dependency is built with, see dependencyVersion.
+func (t TemplateHelmChartTask) validateDependencies(file, version string, chartYaml []byte) error {
+	var metadata chart.Metadata
+	err := yaml.Unmarshal(chartYaml, &metadata)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, dep := range metadata.Dependencies {
+		if !strings.HasPrefix(dep.Repository, localRepositoryPrefix) {
+			continue
+		}
+		version := t.dependencyVersion(file, dep.Repository, version)
+		if dep.Version == version {
+			continue
+		}
+
+		ok := false
+		{
+			c, cErr := semver.NewConstraint(dep.Version)
+			v, vErr := semver.NewVersion(version)
+			if cErr == nil && vErr == nil {
+				ok = c.Check(v)
+			}
+		}
+		if !ok {
+			return microerror.Maskf(
+				validationFailedError,
+				"version constraint %#q of in-repo dependency %#q in %#q doesn't match version being built %#q, consider setting `version: \"[[ .Version ]]\"` for it",
+				dep.Version, dep.Name, file, version,
+			)
+		}
+	}
+
+	return nil
+}
+
+// pinLocks updates the rendered Chart.lock files among files so that every
+// in-repo dependency is locked to the version it is built with, see
+// dependencyVersion, and regenerates their digest the same way `helm
+// dependency update` does. With validate, lock files listing a different set
+// of dependencies than their Chart.yaml are rejected. Lock files which don't
+// change are left byte for byte untouched.
+func (t TemplateHelmChartTask) pinLocks(files []string, rendered [][]byte, version string, validate bool) error {
+	charts := map[string][]byte{}
+	for i, file := range files {
+		if path.Base(file) == HelmChartYamlName {
+			charts[path.Dir(file)] = rendered[i]
+		}
+	}
+
+	for i, file := range files {
+		if path.Base(file) != HelmChartLockName {
+			continue
+		}
+
+		var metadata chart.Metadata
+		err := yaml.Unmarshal(charts[path.Dir(file)], &metadata)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		var lock chart.Lock
+		err = yaml.Unmarshal(rendered[i], &lock)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if validate {
+			err = checkLockInSync(file, metadata.Dependencies, lock.Dependencies)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		for _, dep := range lock.Dependencies {
+			if strings.HasPrefix(dep.Repository, localRepositoryPrefix) {
+				dep.Version = t.dependencyVersion(file, dep.Repository, version)
+			}
+		}
+
+		digest, err := hashReq(metadata.Dependencies, lock.Dependencies)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if digest == lock.Digest {
+			continue
+		}
+		lock.Digest = digest
+
+		rendered[i], err = yaml.Marshal(lock)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// dependencyVersion returns the version the in-repo dependency at repository,
+// e.g. `file://../my-app-crds`, of the chart whose Chart.yaml or Chart.lock
+// is file is built with: its entry in DependencyVersions if there is one,
+// version otherwise.
+func (t TemplateHelmChartTask) dependencyVersion(file, repository, version string) string {
+	dir := filepath.Join(t.chartDir, filepath.Dir(file), strings.TrimPrefix(repository, localRepositoryPrefix))
+	if v, ok := t.dependencyVersions[filepath.Clean(dir)]; ok {
+		return v
+	}
+	return version
+}
+
+// checkLockInSync makes sure every dependency is locked exactly once and the
+// lock has no other entries.
+func checkLockInSync(file string, deps, locked []*chart.Dependency) error {
+	if len(deps) != len(locked) {
+		return microerror.Maskf(
+			validationFailedError,
+			"%#q locks %d dependencies but its %s lists %d, run `helm dependency update`",
+			file, len(locked), HelmChartYamlName, len(deps),
+		)
+	}
+
+	for _, dep := range deps {
+		found := false
+		for _, l := range locked {
+			if l.Name == dep.Name && l.Repository == dep.Repository {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return microerror.Maskf(
+				validationFailedError,
+				"dependency %#q from %#q is not locked in %#q, run `helm dependency update`",
+				dep.Name, dep.Repository, file,
+			)
+		}
+	}
+
+	return nil
+}
+
+// hashReq mirrors Helm's internal resolver.HashReq, which computes the
+// Chart.lock digest from the Chart.yaml dependencies and the locked ones.
+// Repository aliases (`@name`) are hashed as written, whereas Helm resolves
+// them before hashing.
+func hashReq(req, lock []*chart.Dependency) (string, error) {
+	data, err := json.Marshal([2][]*chart.Dependency{req, lock})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	s, err := provenance.Digest(bytes.NewBuffer(data))
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return "sha256:" + s, nil
+}
@@ -0,0 +1,133 @@
+package helmtemplate
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/yaml"
+)
+
+// FileDiff describes how templating changed a single chart file.
+type FileDiff struct {
+	// Path is the path of the file relative to the chart directory.
+	Path string `json:"path"`
+	// ChangedKeys are the dotted paths of the YAML values which differ after
+	// templating. It is empty for files which aren't a single YAML document.
+	ChangedKeys []string `json:"changedKeys,omitempty"`
+	// UnifiedDiff is the unified diff between the original and the templated
+	// file.
+	UnifiedDiff string `json:"-"`
+}
+
+// Diff compares the templated files in the task's filesystem with their
+// originals in base, e.g. after Run has written into a copy-on-write layer
+// over base. Unchanged files are omitted.
+func (t TemplateHelmChartTask) Diff(base afero.Fs) ([]FileDiff, error) {
+	files, err := t.templateFiles()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var diffs []FileDiff
+	for _, file := range files {
+		p := path.Join(t.chartDir, file)
+
+		original, err := afero.ReadFile(base, p)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		templated, err := afero.ReadFile(t.fs, p)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		if string(original) == string(templated) {
+			continue
+		}
+
+		unified, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(original),
+			B:        splitLines(templated),
+			FromFile: path.Join("a", file),
+			ToFile:   path.Join("b", file),
+			Context:  3,
+		})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		diffs = append(diffs, FileDiff{
+			Path:        file,
+			ChangedKeys: changedKeys(original, templated),
+			UnifiedDiff: unified,
+		})
+	}
+
+	return diffs, nil
+}
+
+// splitLines splits data into newline terminated lines. Unlike
+// difflib.SplitLines it doesn't produce a phantom empty line for data ending
+// with a newline.
+func splitLines(data []byte) []string {
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+// changedKeys returns the sorted leaf paths of templated whose value differs
+// from the value at the same path in original. Placeholders like
+// `[[ .Version ]]` are valid YAML (nested flow sequences), so only paths
+// present in the templated document are reported.
+func changedKeys(original, templated []byte) []string {
+	var before, after interface{}
+	if err := yaml.Unmarshal(original, &before); err != nil {
+		return nil
+	}
+	if err := yaml.Unmarshal(templated, &after); err != nil {
+		return nil
+	}
+
+	beforeLeaves := map[string]interface{}{}
+	flatten("", before, beforeLeaves)
+	afterLeaves := map[string]interface{}{}
+	flatten("", after, afterLeaves)
+
+	var keys []string
+	for k, v := range afterLeaves {
+		if b, ok := beforeLeaves[k]; !ok || !reflect.DeepEqual(b, v) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func flatten(prefix string, v interface{}, leaves map[string]interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, c := range v {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flatten(key, c, leaves)
+		}
+	case []interface{}:
+		for i, c := range v {
+			flatten(fmt.Sprintf("%s[%d]", prefix, i), c, leaves)
+		}
+	default:
+		leaves[prefix] = v
+	}
+}
@@ -0,0 +1,132 @@
+package helmtemplate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+// TestDiff tests templating into a copy-on-write layer leaves the base
+// filesystem untouched and reports the changed files and keys.
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		name                string
+		files               map[string]string
+		expectedChangedKeys map[string][]string
+		expectedDiffLines   []string
+	}{
+		{
+			name: "case 0: changed chart and values",
+			files: map[string]string{
+				HelmChartYamlName:  "name: test\nversion: [[ .Version ]]\nappVersion: \"[[ .AppVersion ]]\"\n",
+				HelmValuesYamlName: "image:\n  tag: [[ .Version ]]\nreplicas: 1\n",
+			},
+			expectedChangedKeys: map[string][]string{
+				HelmChartYamlName:  {"appVersion", "version"},
+				HelmValuesYamlName: {"image.tag"},
+			},
+			expectedDiffLines: []string{
+				"--- a/Chart.yaml",
+				"+++ b/Chart.yaml",
+				"-version: [[ .Version ]]",
+				"+version: 1.2.3",
+				"+  tag: 1.2.3",
+			},
+		},
+		{
+			name: "case 1: nothing to template",
+			files: map[string]string{
+				HelmChartYamlName:  "version: 1.2.3\n",
+				HelmValuesYamlName: "replicas: 1\n",
+			},
+			expectedChangedKeys: map[string][]string{},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			base := afero.NewMemMapFs()
+			config := Config{
+				Fs:         base,
+				ChartDir:   "/tmp/architect-testdiff",
+				Branch:     "master",
+				Sha:        "ea82e754178bb2b8065aca0a0760e77ce3733649",
+				Version:    "1.2.3",
+				AppVersion: "1.0.0",
+			}
+
+			err := setup(config, tc.files)
+			if err != nil {
+				t.Fatalf("unexpected error during setup: %v\n", err)
+			}
+
+			config.Fs = afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(base), afero.NewMemMapFs())
+			task, err := NewTemplateHelmChartTask(config)
+			if err != nil {
+				t.Fatalf("unexpected error when creating NewTemplateHelmChartTask: %v\n", err)
+			}
+
+			result, err := task.Run(false, false)
+			if err != nil {
+				t.Fatalf("unexpected error during Run: %v\n", err)
+			}
+
+			diffs, err := task.Diff(base)
+			if err != nil {
+				t.Fatalf("unexpected error during Diff: %v\n", err)
+			}
+
+			changedKeys := map[string][]string{}
+			var unified string
+			for _, d := range diffs {
+				changedKeys[d.Path] = d.ChangedKeys
+				unified += d.UnifiedDiff
+			}
+
+			if diff := cmp.Diff(tc.expectedChangedKeys, changedKeys); diff != "" {
+				t.Errorf("changed keys mismatch (-want +got):\n%s", diff)
+			}
+
+			// Run reports the same files as modified, with the checksum of
+			// their templated content.
+			modified := map[string][]string{}
+			for _, f := range result.ModifiedFiles {
+				modified[f.Path] = changedKeys[f.Path]
+
+				templated, err := afero.ReadFile(config.Fs, config.ChartDir+"/"+f.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if sum := sha256.Sum256(templated); hex.EncodeToString(sum[:]) != f.SHA256 {
+					t.Errorf("checksum of %#q == %s, want %x", f.Path, f.SHA256, sum)
+				}
+			}
+			if diff := cmp.Diff(changedKeys, modified); diff != "" {
+				t.Errorf("modified files mismatch (-diff +run):\n%s", diff)
+			}
+
+			for _, line := range tc.expectedDiffLines {
+				if !strings.Contains(unified, line+"\n") {
+					t.Errorf("expected line %#q in diff:\n%s", line, unified)
+				}
+			}
+
+			for file, data := range tc.files {
+				original, err := afero.ReadFile(base, config.ChartDir+"/"+file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(original) != data {
+					t.Errorf("base file %#q was modified", file)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package helmtemplate
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+)
+
+// DiscoverCharts returns the directories of all charts found under root, in
+// lexical order. A chart is a directory containing Chart.yaml. Subcharts
+// vendored under a chart's charts/ directory and hidden directories such as
+// .git are not returned.
+func DiscoverCharts(fs afero.Fs, root string) ([]string, error) {
+	var charts []string
+
+	err := afero.Walk(fs, root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if p != root && info.Name()[0] == '.' {
+			return filepath.SkipDir
+		}
+
+		if info.Name() == HelmSubchartDirectoryName {
+			isChart, err := afero.Exists(fs, filepath.Join(filepath.Dir(p), HelmChartYamlName))
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			if isChart {
+				return filepath.SkipDir
+			}
+		}
+
+		isChart, err := afero.Exists(fs, filepath.Join(p, HelmChartYamlName))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if isChart {
+			charts = append(charts, p)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return charts, nil
+}
@@ -0,0 +1,71 @@
+package helmtemplate
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+// TestDiscoverCharts tests DiscoverCharts finds top level charts only.
+func TestDiscoverCharts(t *testing.T) {
+	testCases := []struct {
+		name           string
+		files          []string
+		expectedCharts []string
+	}{
+		{
+			name: "case 0: single chart",
+			files: []string{
+				"helm/app/Chart.yaml",
+				"helm/app/templates/deployment.yaml",
+			},
+			expectedCharts: []string{"/repo/helm/app"},
+		},
+		{
+			name: "case 1: multiple charts, subcharts and hidden directories",
+			files: []string{
+				".git/helm/Chart.yaml",
+				"helm/b/Chart.yaml",
+				"helm/b/charts/sub/Chart.yaml",
+				"helm/a/Chart.yaml",
+				"helm/a/templates/deployment.yaml",
+				"other/nested/c/Chart.yaml",
+			},
+			expectedCharts: []string{
+				"/repo/helm/a",
+				"/repo/helm/b",
+				"/repo/other/nested/c",
+			},
+		},
+		{
+			name:  "case 2: no charts",
+			files: []string{"README.md"},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			fs := afero.NewMemMapFs()
+			for _, f := range tc.files {
+				err := afero.WriteFile(fs, filepath.Join("/repo", f), []byte{}, permission)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			charts, err := DiscoverCharts(fs, "/repo")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expectedCharts, charts); diff != "" {
+				t.Errorf("charts mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -21,3 +21,21 @@ var validationFailedError = &microerror.Error{
 func IsValidationFailedError(err error) bool {
 	return microerror.Cause(err) == validationFailedError
 }
+
+var notTemplatedError = &microerror.Error{
+	Kind: "notTemplatedError",
+}
+
+// IsNotTemplated asserts notTemplatedError.
+func IsNotTemplated(err error) bool {
+	return microerror.Cause(err) == notTemplatedError
+}
+
+var modifiedSinceTemplatingError = &microerror.Error{
+	Kind: "modifiedSinceTemplatingError",
+}
+
+// IsModifiedSinceTemplating asserts modifiedSinceTemplatingError.
+func IsModifiedSinceTemplating(err error) bool {
+	return microerror.Cause(err) == modifiedSinceTemplatingError
+}
@@ -0,0 +1,82 @@
+package helmtemplate
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/giantswarm/microerror"
+)
+
+// shortSHALength is the number of characters kept by the shortSHA template
+// function, matching `git rev-parse --short`.
+const shortSHALength = 7
+
+var semverRegexp = regexp.MustCompile(`^v?([0-9]+)\.([0-9]+)\.([0-9]+)(?:[-+].*)?$`)
+
+// templateFuncs is the set of functions available inside `[[ ]]` actions.
+// Functions taking the templated value take it as their last argument so
+// they can be used in pipelines, e.g. `[[ .Version | trimPrefix "v" ]]`.
+var templateFuncs = template.FuncMap{
+	"default":     defaultValue,
+	"lower":       strings.ToLower,
+	"quote":       strconv.Quote,
+	"semverMajor": semverMajor,
+	"semverMinor": semverMinor,
+	"semverPatch": semverPatch,
+	"shortSHA":    shortSHA,
+	"trimPrefix":  trimPrefix,
+	"trimSuffix":  trimSuffix,
+	"upper":       strings.ToUpper,
+}
+
+// defaultValue returns def when value is empty. Note that `.Extra.key` fails
+// for undefined keys, use `index .Extra "key" | default "value"` instead.
+func defaultValue(def, value string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+func semverMajor(version string) (int, error) {
+	return semverPart(version, 1)
+}
+
+func semverMinor(version string) (int, error) {
+	return semverPart(version, 2)
+}
+
+func semverPatch(version string) (int, error) {
+	return semverPart(version, 3)
+}
+
+func semverPart(version string, i int) (int, error) {
+	match := semverRegexp.FindStringSubmatch(version)
+	if match == nil {
+		return 0, microerror.Maskf(invalidConfigError, "%#q is not a semantic version", version)
+	}
+
+	n, err := strconv.Atoi(match[i])
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	return n, nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) <= shortSHALength {
+		return sha
+	}
+	return sha[:shortSHALength]
+}
+
+func trimPrefix(prefix, s string) string {
+	return strings.TrimPrefix(s, prefix)
+}
+
+func trimSuffix(suffix, s string) string {
+	return strings.TrimSuffix(s, suffix)
+}
@@ -3,8 +3,11 @@ package helmtemplate
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"path"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"text/template"
 
 	"github.com/giantswarm/microerror"
@@ -21,8 +24,32 @@ const (
 	// HelmTemplateDirectoryName is the name of the directory that stores
 	// Kubernetes resources inside a chart.
 	HelmTemplateDirectoryName = "templates"
+	// HelmCRDDirectoryName is the name of the directory that stores custom
+	// resource definitions inside a chart.
+	HelmCRDDirectoryName = "crds"
+	// HelmSubchartDirectoryName is the name of the directory that stores
+	// vendored subcharts inside a chart.
+	HelmSubchartDirectoryName = "charts"
+	// HelmChartLockName is the name of the file that locks the versions of
+	// the chart's dependencies.
+	HelmChartLockName = "Chart.lock"
 )
 
+// helmTemplateDelim is the opening delimiter of Helm's own template actions.
+// Files containing it are left untouched so that architect's `[[ ]]` pass
+// can't interfere with what Helm renders at install time.
+const helmTemplateDelim = "{{"
+
+// architectActionRegexp matches the start of an architect `[[ ]]` action in
+// a manifest, which begins with a field such as `.Version` or an identifier
+// such as `if` or a template function. Literal `[[` in plain manifests, e.g.
+// bash tests like `[[ -f file ]]` or `[[ $x == y ]]` in a ConfigMap, don't
+// match, so such manifests are left untouched.
+var architectActionRegexp = regexp.MustCompile(`\[\[-?\s*[.A-Za-z_]`)
+
+// extraKeyRegexp matches keys which can be referenced as `[[ .Extra.key ]]`.
+var extraKeyRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 // TemplateHelmChartTask is used to run a template-helm-chart command
 type TemplateHelmChartTask struct {
 	fs afero.Fs
@@ -32,23 +59,85 @@ type TemplateHelmChartTask struct {
 	sha                 string
 	chartVersion        string
 	appVersion          string
+	appVersionSource    string
 	skipAppVersionCheck bool
+	strict              bool
+	lintMetadata        bool
+	extra               map[string]string
+	journalFile         string
+	validateImages      bool
+	registry            string
+	imageRepository     string
+	imagePaths          []string
+	rewriteRegistry     bool
+	changelogFile       string
+	dependencyVersions  map[string]string
 }
 
 // Config holds configuration for building a new TemplateHelmChartTask
 type Config struct {
 	Fs afero.Fs
 
-	ChartDir            string
-	Branch              string
-	Sha                 string
-	Version             string
-	AppVersion          string
+	ChartDir   string
+	Branch     string
+	Sha        string
+	Version    string
+	AppVersion string
+	// AppVersionSource describes where AppVersion was read from, e.g.
+	// `pkg/project/project.go:version`. It is only used in error messages.
+	AppVersionSource    string
 	SkipAppVersionCheck bool
+	// Strict makes Run statically check every `[[ ]]` reference to build info,
+	// including those in branches which wouldn't be executed, and report all
+	// unresolved ones with their file and line before templating anything.
+	Strict bool
+	// LintMetadata makes validation also check Chart.yaml against Giant
+	// Swarm's chart metadata conventions, see LintMetadata.
+	LintMetadata bool
+	// Extra holds user defined build information exposed to templates as
+	// `[[ .Extra.key ]]`. Keys must be valid template identifiers.
+	Extra map[string]string
+	// ValidateImages makes Run check that the image references found at
+	// ImagePaths in the rendered values.yaml use Registry and
+	// ImageRepository and are tagged with the version being built.
+	ValidateImages bool
+	// Registry is the expected registry of image references. It isn't
+	// checked if empty.
+	Registry string
+	// ImageRepository is the expected repository of image references, e.g.
+	// `giantswarm/my-app`. It isn't checked if empty.
+	ImageRepository string
+	// ImagePaths are the dotted paths of image references in values.yaml. A
+	// `*` segment matches every value of a mapping. Defaults to
+	// DefaultImagePaths.
+	ImagePaths []string
+	// RewriteRegistry makes Run set the registry of every image reference
+	// to Registry instead of failing validation.
+	RewriteRegistry bool
+	// ChangelogFile, if set, is the CHANGELOG.md the artifacthub.io/changes
+	// annotation of the chart's Chart.yaml is generated from, using the
+	// section of Version.
+	ChangelogFile string
+	// DependencyVersions are the versions in-repo (`file://`) dependencies
+	// are pinned to and validated against, by the directory of their chart.
+	// Dependencies which aren't listed are built with Version, as charts of
+	// the same repository usually are. It is needed when sibling charts are
+	// built with their own versions.
+	DependencyVersions map[string]string
+	// JournalFile, if set, is where Run records the original content of the
+	// files it changes, so that Untemplate can revert them. See JournalFile.
+	JournalFile string
 }
 
-// Run templates the chart's Chart.yaml and templates/deployment.yaml.
-func (t TemplateHelmChartTask) Run(validate, tagBuild bool) error {
+// Run templates the chart's Chart.yaml, values.yaml, Chart.lock and every file
+// under templates/ and crds/ which doesn't contain Helm template actions, and
+// does the same for every subchart vendored under charts/. Chart.lock files
+// are then updated to pin in-repo dependencies to the version they are built
+// with.
+// With a changelog configured, the artifacthub.io/changes annotation of the
+// chart's Chart.yaml is set from it.
+// The returned Result describes the build info used and the files changed.
+func (t TemplateHelmChartTask) Run(validate, tagBuild bool) (Result, error) {
 	// Check if version is the reference version
 	//
 	// Reference version is version like `v0.1.0-1`
@@ -60,52 +149,258 @@ func (t TemplateHelmChartTask) Run(validate, tagBuild bool) error {
 		}
 	}
 
-	// We expect versions to match for a tagged build if pkg/project/project.go
-	// file has been found. Otherwise (project.go not found) t.appVersion will
-	// be empty, which will always be the case for Managed Apps as appVersion will
-	// refer to the version of the application being installed.
+	// We expect versions to match for a tagged build if the app version has
+	// been found in the repository. Otherwise t.appVersion will be empty, which
+	// will always be the case for Managed Apps as appVersion will refer to the
+	// version of the application being installed.
 	if validate && tagBuild && t.appVersion != "" && t.chartVersion != t.appVersion && !t.skipAppVersionCheck && !refVersion {
-		return microerror.Maskf(
+		return Result{}, microerror.Maskf(
 			validationFailedError,
-			"version in git tag must be equal to version in pkg/project/project.go: %#q != %#q, this release is **broken**, create another one",
-			t.chartVersion, t.appVersion,
+			"version in git tag must be equal to version in %s: %#q != %#q, this release is **broken**, create another one",
+			t.appVersionSourceName(), t.chartVersion, t.appVersion,
 		)
 	}
-	for _, file := range []string{HelmChartYamlName, HelmValuesYamlName} {
+
+	buildInfo := BuildInfo{
+		Branch:     t.branch,
+		SHA:        t.sha,
+		Version:    t.chartVersion,
+		AppVersion: t.appVersion,
+		Extra:      t.extra,
+	}
+
+	files, err := t.templateFiles()
+	if err != nil {
+		return Result{}, microerror.Mask(err)
+	}
+
+	// Render every file before writing any of them, so that a broken
+	// template doesn't leave the chart half templated.
+	original := make([][]byte, len(files))
+	rendered := make([][]byte, len(files))
+	var unresolved []string
+	for i, file := range files {
 		path := path.Join(t.chartDir, file)
 		contents, err := afero.ReadFile(t.fs, path)
 		if err != nil {
-			return microerror.Mask(err)
+			return Result{}, microerror.Mask(err)
 		}
+		original[i] = contents
 
-		buildInfo := BuildInfo{
-			Branch:     t.branch,
-			SHA:        t.sha,
-			Version:    t.chartVersion,
-			AppVersion: t.appVersion,
+		// Referencing a key missing from .Extra must fail instead of
+		// rendering "<no value>" into the chart.
+		tmpl, err := template.New(path).Delims("[[", "]]").Option("missingkey=error").Funcs(templateFuncs).Parse(string(contents))
+		if err != nil {
+			return Result{}, microerror.Mask(err)
 		}
 
-		tmpl, err := template.New(path).Delims("[[", "]]").Parse(string(contents))
-		if err != nil {
-			return microerror.Mask(err)
+		if t.strict {
+			unresolved = append(unresolved, unresolvedReferences(tmpl, buildInfo)...)
+			if len(unresolved) > 0 {
+				continue
+			}
 		}
 
 		var buf bytes.Buffer
 		if err := tmpl.Execute(&buf, buildInfo); err != nil {
-			return microerror.Mask(err)
+			return Result{}, microerror.Mask(err)
+		}
+
+		if file == HelmChartYamlName && t.changelogFile != "" {
+			changes, err := t.artifactHubChanges(buildInfo.Version, tagBuild)
+			if err != nil {
+				return Result{}, microerror.Mask(err)
+			}
+			if len(changes) > 0 {
+				chart, err := setArtifactHubChanges(buf.Bytes(), changes)
+				if err != nil {
+					return Result{}, microerror.Mask(err)
+				}
+				buf.Reset()
+				buf.Write(chart)
+			}
 		}
 
 		if file == HelmChartYamlName && validate {
 			if err := validateChart(t.skipAppVersionCheck, buildInfo.Version, buildInfo.AppVersion, buf); err != nil {
+				return Result{}, microerror.Mask(err)
+			}
+		}
+
+		if file == HelmChartYamlName && validate && t.lintMetadata {
+			findings, err := LintMetadata(t.chartDir, buf.Bytes())
+			if err != nil {
+				return Result{}, microerror.Mask(err)
+			}
+			if err := lintMetadataError(file, findings); err != nil {
+				return Result{}, microerror.Mask(err)
+			}
+		}
+
+		if filepath.Base(file) == HelmChartYamlName && validate {
+			if err := t.validateDependencies(file, buildInfo.Version, buf.Bytes()); err != nil {
+				return Result{}, microerror.Mask(err)
+			}
+		}
+
+		// Image references are checked, and possibly rewritten, before the
+		// values are validated against their schema.
+		if file == HelmValuesYamlName && (t.validateImages || t.rewriteRegistry) {
+			values, err := t.checkImages(file, buf.Bytes(), buildInfo)
+			if err != nil {
+				return Result{}, microerror.Mask(err)
+			}
+			buf.Reset()
+			buf.Write(values)
+		}
+
+		if filepath.Base(file) == HelmValuesYamlName && validate {
+			if err := t.validateValues(file, buf.Bytes()); err != nil {
+				return Result{}, microerror.Mask(err)
+			}
+		}
+
+		rendered[i] = buf.Bytes()
+	}
+
+	if len(unresolved) > 0 {
+		return Result{}, microerror.Maskf(
+			validationFailedError,
+			"unresolved template references:\n%s",
+			strings.Join(unresolved, "\n"),
+		)
+	}
+
+	if err := t.pinLocks(files, rendered, buildInfo.Version, validate); err != nil {
+		return Result{}, microerror.Mask(err)
+	}
+
+	// Record the original files before overwriting any of them.
+	if t.journalFile != "" {
+		if err := t.writeJournal(files, original, rendered); err != nil {
+			return Result{}, microerror.Mask(err)
+		}
+	}
+
+	result := Result{
+		BuildInfo: buildInfo,
+	}
+	for i, file := range files {
+		if err := afero.WriteFile(t.fs, path.Join(t.chartDir, file), rendered[i], permission); err != nil {
+			return Result{}, microerror.Mask(err)
+		}
+
+		if !bytes.Equal(original[i], rendered[i]) {
+			result.ModifiedFiles = append(result.ModifiedFiles, TemplatedFile{
+				Path:   file,
+				SHA256: sha256Hex(rendered[i]),
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// templateFiles returns the paths, relative to the chart directory, of all
+// files to be templated, see chartFiles.
+func (t TemplateHelmChartTask) templateFiles() ([]string, error) {
+	files, err := t.chartFiles("", true)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return files, nil
+}
+
+// chartFiles returns the files to be templated for the chart at dir, relative
+// to the task's chart directory. Chart.yaml and values.yaml come first,
+// followed by Chart.lock if it exists and the manifests found in templates/
+// and crds/ in lexical order. Only manifests containing architect actions
+// are templated and manifests containing Helm template actions are skipped. Subcharts vendored as directories under charts/ are templated
+// recursively. Only the top level chart is required to have values.yaml.
+func (t TemplateHelmChartTask) chartFiles(dir string, topLevel bool) ([]string, error) {
+	var files []string
+
+	for _, file := range []string{HelmChartYamlName, HelmValuesYamlName, HelmChartLockName} {
+		exists, err := afero.Exists(t.fs, path.Join(t.chartDir, dir, file))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		if exists || (topLevel && file != HelmChartLockName) {
+			files = append(files, path.Join(dir, file))
+		}
+	}
+
+	for _, d := range []string{HelmTemplateDirectoryName, HelmCRDDirectoryName} {
+		root := path.Join(t.chartDir, dir, d)
+
+		exists, err := afero.DirExists(t.fs, root)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		if !exists {
+			continue
+		}
+
+		err = afero.Walk(t.fs, root, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
 				return microerror.Mask(err)
 			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+
+			contents, err := afero.ReadFile(t.fs, p)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			if bytes.Contains(contents, []byte(helmTemplateDelim)) || !architectActionRegexp.Match(contents) {
+				return nil
+			}
+
+			rel, err := filepath.Rel(t.chartDir, p)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			files = append(files, filepath.ToSlash(rel))
+
+			return nil
+		})
+		if err != nil {
+			return nil, microerror.Mask(err)
 		}
+	}
+
+	subcharts, err := afero.ReadDir(t.fs, path.Join(t.chartDir, dir, HelmSubchartDirectoryName))
+	if os.IsNotExist(err) {
+		return files, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
 
-		if err := afero.WriteFile(t.fs, path, buf.Bytes(), permission); err != nil {
-			return microerror.Mask(err)
+	for _, info := range subcharts {
+		if !info.IsDir() {
+			// Packaged subcharts (.tgz) are not templated.
+			continue
+		}
+
+		subchart := path.Join(dir, HelmSubchartDirectoryName, info.Name())
+		isChart, err := afero.Exists(t.fs, path.Join(t.chartDir, subchart, HelmChartYamlName))
+		if err != nil {
+			return nil, microerror.Mask(err)
 		}
+		if !isChart {
+			continue
+		}
+
+		subFiles, err := t.chartFiles(subchart, false)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		files = append(files, subFiles...)
 	}
-	return nil
+
+	return files, nil
 }
 
 // validateChart makes sure version fields and values made it into the
@@ -125,7 +420,7 @@ func validateChart(skipAppVersionCheck bool, version, appVersion string, chartBu
 		)
 	}
 
-	// We want to validate appVersion only when the project.go has been found,
+	// We want to validate appVersion only when it has been found in the repository,
 	// i.e. appVersion is non-empty.
 	if appVersion != "" && chart.AppVersion != appVersion && !skipAppVersionCheck {
 		return microerror.Maskf(
@@ -138,6 +433,15 @@ func validateChart(skipAppVersionCheck bool, version, appVersion string, chartBu
 	return nil
 }
 
+// appVersionSourceName returns where the app version was read from, for use
+// in error messages.
+func (t TemplateHelmChartTask) appVersionSourceName() string {
+	if t.appVersionSource == "" {
+		return "the project"
+	}
+	return t.appVersionSource
+}
+
 func (t TemplateHelmChartTask) String() string {
 	return fmt.Sprintf("%s:\t%s sha:%s chartVersion:%s appVersion:%s", "template-helm-chart", t.chartDir, t.sha, t.chartVersion, t.appVersion)
 }
@@ -164,6 +468,25 @@ func NewTemplateHelmChartTask(config Config) (*TemplateHelmChartTask, error) {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Version must not be empty", config)
 	}
 
+	for key := range config.Extra {
+		if !extraKeyRegexp.MatchString(key) {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Extra key %#q must match %#q", config, key, extraKeyRegexp)
+		}
+	}
+
+	if config.RewriteRegistry && config.Registry == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Registry must not be empty when %T.RewriteRegistry is set", config, config)
+	}
+
+	if len(config.ImagePaths) == 0 {
+		config.ImagePaths = DefaultImagePaths
+	}
+
+	dependencyVersions := map[string]string{}
+	for dir, version := range config.DependencyVersions {
+		dependencyVersions[filepath.Clean(dir)] = version
+	}
+
 	t := &TemplateHelmChartTask{
 		fs:                  config.Fs,
 		chartDir:            config.ChartDir,
@@ -171,7 +494,19 @@ func NewTemplateHelmChartTask(config Config) (*TemplateHelmChartTask, error) {
 		sha:                 config.Sha,
 		chartVersion:        config.Version,
 		appVersion:          config.AppVersion,
+		appVersionSource:    config.AppVersionSource,
 		skipAppVersionCheck: config.SkipAppVersionCheck,
+		strict:              config.Strict,
+		lintMetadata:        config.LintMetadata,
+		extra:               config.Extra,
+		journalFile:         config.JournalFile,
+		validateImages:      config.ValidateImages,
+		registry:            config.Registry,
+		imageRepository:     config.ImageRepository,
+		imagePaths:          config.ImagePaths,
+		rewriteRegistry:     config.RewriteRegistry,
+		changelogFile:       config.ChangelogFile,
+		dependencyVersions:  dependencyVersions,
 	}
 
 	return t, nil
@@ -91,6 +91,199 @@ func TestTemplateHelmChartTask(t *testing.T) {
 			taggedBuildFlag: true,
 			errorMatcher:    IsValidationFailedError,
 		},
+		{
+			name: "case 5: template manifests without helm template actions",
+			config: Config{
+				Branch:     "master",
+				Sha:        "ea82e754178bb2b8065aca0a0760e77ce3733649",
+				Version:    "1.2.3",
+				AppVersion: "1.0.0",
+			},
+			files: map[string]string{
+				HelmChartYamlName:                 "version: [[ .Version ]]\nappVersion: [[ .AppVersion ]]\n",
+				HelmValuesYamlName:                "branch: [[ .Branch ]]\ncommit: [[ .SHA ]]\n",
+				"templates/configmap.yaml":        "metadata:\n  labels:\n    version: [[ .Version ]]\n",
+				"templates/deployment.yaml":       "metadata:\n  name: {{ .Release.Name }}\n  labels:\n    version: [[ .Version ]]\n",
+				"crds/example.giantswarm.io.yaml": "metadata:\n  annotations:\n    commit: [[ .SHA ]]\n",
+			},
+			expectedChartDir: "test2",
+		},
+		{
+			name: "case 6: template extra build info",
+			config: Config{
+				Branch:     "master",
+				Sha:        "ea82e754178bb2b8065aca0a0760e77ce3733649",
+				Version:    "1.2.3",
+				AppVersion: "1.0.0",
+				Extra: map[string]string{
+					"buildTimestamp": "2026-01-02T03:04:05Z",
+					"registry":       "gsoci.azurecr.io",
+				},
+			},
+			files: map[string]string{
+				HelmChartYamlName:  "version: [[ .Version ]]\nappVersion: [[ .AppVersion ]]\n",
+				HelmValuesYamlName: "registry: [[ .Extra.registry ]]\nbuildTimestamp: [[ .Extra.buildTimestamp ]]\n",
+			},
+			expectedChartDir: "test3",
+		},
+		{
+			name: "case 7: missing extra build info",
+			config: Config{
+				Branch:  "master",
+				Sha:     "ea82e754178bb2b8065aca0a0760e77ce3733649",
+				Version: "1.2.3",
+				Extra: map[string]string{
+					"registry": "gsoci.azurecr.io",
+				},
+			},
+			files: map[string]string{
+				HelmChartYamlName:  "version: [[ .Version ]]\n",
+				HelmValuesYamlName: "pipeline: [[ .Extra.pipelineURL ]]\n",
+			},
+			errorMatcher: func(err error) bool { return err != nil },
+		},
+		{
+			name: "case 8: template functions",
+			config: Config{
+				Branch:     "master",
+				Sha:        "ea82e754178bb2b8065aca0a0760e77ce3733649",
+				Version:    "v1.2.3-rc.1",
+				AppVersion: "1.0.0",
+				Extra: map[string]string{
+					"registry": "GSOCI.azurecr.io",
+				},
+			},
+			files: map[string]string{
+				HelmChartYamlName:  "version: [[ .Version | trimPrefix \"v\" ]]\nappVersion: [[ .AppVersion | quote ]]\n",
+				HelmValuesYamlName: "major: [[ semverMajor .Version ]]\nminor: [[ semverMinor .Version ]]\nsha: [[ shortSHA .SHA | upper ]]\nregistry: [[ .Extra.registry | lower ]]\nmirror: [[ index .Extra \"mirror\" | default \"docker.io\" ]]\n",
+			},
+			expectedChartDir: "test4",
+		},
+		{
+			name: "case 9: strict mode reports unresolved references",
+			config: Config{
+				Branch:  "master",
+				Sha:     "ea82e754178bb2b8065aca0a0760e77ce3733649",
+				Version: "1.2.3",
+				Strict:  true,
+			},
+			files: map[string]string{
+				HelmChartYamlName:  "version: [[ .Version ]]\n",
+				HelmValuesYamlName: "[[ if false ]]\nversion: [[ .Verison ]]\n[[ end ]]\n",
+			},
+			errorMatcher: IsValidationFailedError,
+		},
+		{
+			name: "case 10: template subcharts and pin in-repo dependencies in Chart.lock",
+			config: Config{
+				Branch:     "master",
+				Sha:        "ea82e754178bb2b8065aca0a0760e77ce3733649",
+				Version:    "1.2.3",
+				AppVersion: "1.0.0",
+			},
+			files: map[string]string{
+				HelmChartYamlName:             "version: [[ .Version ]]\nappVersion: [[ .AppVersion ]]\ndependencies:\n- name: crds\n  version: \"[[ .Version ]]\"\n  repository: file://../crds\n- name: redis\n  version: 1.0.0\n  repository: https://charts.example.com\n",
+				HelmValuesYamlName:            "branch: [[ .Branch ]]\ncommit: [[ .SHA ]]\n",
+				HelmChartLockName:             "dependencies:\n- name: crds\n  repository: file://../crds\n  version: 1.2.2\n- name: redis\n  repository: https://charts.example.com\n  version: 1.0.0\ndigest: sha256:0000000000000000000000000000000000000000000000000000000000000000\ngenerated: \"2026-01-02T03:04:05.000000006Z\"\n",
+				"charts/sub/Chart.yaml":       "name: sub\nversion: [[ .Version ]]\n",
+				"charts/sub/templates/a.yaml": "sha: [[ .SHA ]]\n",
+				"charts/vendored/Chart.yaml":  "name: vendored\nversion: 0.1.0\n",
+			},
+			validateFlag:     true,
+			expectedChartDir: "test5",
+		},
+		{
+			name: "case 11: in-repo dependency version validation failure",
+			config: Config{
+				Branch:  "master",
+				Sha:     "ea82e754178bb2b8065aca0a0760e77ce3733649",
+				Version: "1.2.3",
+			},
+			files: map[string]string{
+				HelmChartYamlName:  "version: [[ .Version ]]\ndependencies:\n- name: crds\n  version: ~1.1.0\n  repository: file://../crds\n",
+				HelmValuesYamlName: "",
+			},
+			validateFlag: true,
+			errorMatcher: IsValidationFailedError,
+		},
+		{
+			name: "case 12: Chart.lock out of sync",
+			config: Config{
+				Branch:  "master",
+				Sha:     "ea82e754178bb2b8065aca0a0760e77ce3733649",
+				Version: "1.2.3",
+			},
+			files: map[string]string{
+				HelmChartYamlName:  "version: [[ .Version ]]\ndependencies:\n- name: crds\n  version: \"[[ .Version ]]\"\n  repository: file://../crds\n",
+				HelmValuesYamlName: "",
+				HelmChartLockName:  "dependencies:\n- name: redis\n  repository: https://charts.example.com\n  version: 1.0.0\ndigest: sha256:0000\ngenerated: \"2026-01-02T03:04:05Z\"\n",
+			},
+			validateFlag: true,
+			errorMatcher: IsValidationFailedError,
+		},
+		{
+			name: "case 13: chart metadata validation failure",
+			config: Config{
+				Branch:       "master",
+				Sha:          "ea82e754178bb2b8065aca0a0760e77ce3733649",
+				Version:      "1.2.3",
+				LintMetadata: true,
+			},
+			files: map[string]string{
+				HelmChartYamlName:  "apiVersion: v2\nname: architect-testtemplatehelmcharttask\nversion: [[ .Version ]]\n",
+				HelmValuesYamlName: "",
+			},
+			validateFlag: true,
+			errorMatcher: IsValidationFailedError,
+		},
+		{
+			name: "case 14: Chart.lock out of sync without validation",
+			config: Config{
+				Branch:  "master",
+				Sha:     "ea82e754178bb2b8065aca0a0760e77ce3733649",
+				Version: "1.2.3",
+			},
+			files: map[string]string{
+				HelmChartYamlName:  "version: [[ .Version ]]\ndependencies:\n- name: crds\n  version: \"[[ .Version ]]\"\n  repository: file://../crds\n- name: redis\n  version: 1.0.0\n  repository: https://charts.example.com\n",
+				HelmValuesYamlName: "",
+				HelmChartLockName:  "dependencies:\n- name: crds\n  repository: file://../crds\n  version: 1.2.2\ndigest: sha256:0000000000000000000000000000000000000000000000000000000000000000\ngenerated: \"2026-01-02T03:04:05.000000006Z\"\n",
+			},
+			expectedChartDir: "test6",
+		},
+		{
+			name: "case 15: in-repo dependency built with its own version",
+			config: Config{
+				Branch:  "master",
+				Sha:     "ea82e754178bb2b8065aca0a0760e77ce3733649",
+				Version: "1.2.3",
+				DependencyVersions: map[string]string{
+					"/tmp/crds/": "2.0.0",
+				},
+			},
+			files: map[string]string{
+				HelmChartYamlName:  "version: [[ .Version ]]\ndependencies:\n- name: crds\n  version: ^2.0.0\n  repository: file://../crds\n",
+				HelmValuesYamlName: "",
+				HelmChartLockName:  "dependencies:\n- name: crds\n  repository: file://../crds\n  version: 1.9.0\ndigest: sha256:0000000000000000000000000000000000000000000000000000000000000000\ngenerated: \"2026-01-02T03:04:05.000000006Z\"\n",
+			},
+			validateFlag:     true,
+			expectedChartDir: "test7",
+		},
+		{
+			name: "case 16: manifests with literal brackets left untouched",
+			config: Config{
+				Branch:     "master",
+				Sha:        "ea82e754178bb2b8065aca0a0760e77ce3733649",
+				Version:    "1.2.3",
+				AppVersion: "1.0.0",
+			},
+			files: map[string]string{
+				HelmChartYamlName:          "version: [[ .Version ]]\nappVersion: [[ .AppVersion ]]\n",
+				HelmValuesYamlName:         "branch: [[ .Branch ]]\n",
+				"templates/script.yaml":    "data:\n  run.sh: |\n    if [[ -f /etc/config ]] && [[ $MODE == \"a]]\" ]]; then\n      echo ok\n    fi\n",
+				"templates/configmap.yaml": "metadata:\n  labels:\n    version: [[ .Version ]]\n    major: \"[[ semverMajor .Version ]]\"\n",
+			},
+			expectedChartDir: "test8",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -108,7 +301,7 @@ func TestTemplateHelmChartTask(t *testing.T) {
 				t.Fatalf("unexpected error when creating NewTemplateHelmChartTask: %v\n", err)
 			}
 
-			err = task.Run(tc.validateFlag, tc.taggedBuildFlag)
+			_, err = task.Run(tc.validateFlag, tc.taggedBuildFlag)
 			switch {
 			case err == nil && tc.errorMatcher == nil:
 				err = check(tc.config, tc.expectedChartDir)
@@ -136,7 +329,7 @@ func setup(config Config, files map[string]string) error {
 
 	for fpath, data := range files {
 		path := filepath.Join(config.ChartDir, fpath)
-		dir := filepath.Base(path)
+		dir := filepath.Dir(path)
 		err := config.Fs.MkdirAll(dir, permission)
 		if err != nil {
 			return microerror.Mask(err)
@@ -0,0 +1,254 @@
+package helmtemplate
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"go.yaml.in/yaml/v3"
+)
+
+// DefaultImagePaths are the dotted paths of the image references checked in
+// values.yaml when no paths are configured.
+var DefaultImagePaths = []string{"image"}
+
+// imageReference is an image reference found in values.yaml, either a mapping
+// with registry, repository (or name) and tag keys or a single string such as
+// `gsoci.azurecr.io/giantswarm/app:1.2.3`.
+type imageReference struct {
+	// Path is the dotted path of the reference in values.yaml.
+	Path       string
+	Registry   string
+	Repository string
+	Tag        string
+
+	// node is the YAML node of the reference, used for rewriting it.
+	node *yaml.Node
+}
+
+// checkImages locates the image references at the configured paths of the
+// rendered values and, when image validation is enabled, checks that their
+// registry, repository and tag match the build. A tag matching either the
+// version or the app version is accepted. When registry rewriting is enabled
+// the registry of every reference is set to the expected one instead of being
+// checked. The possibly rewritten values are returned.
+func (t TemplateHelmChartTask) checkImages(file string, values []byte, buildInfo BuildInfo) ([]byte, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(values, &doc)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var refs []imageReference
+	for _, p := range t.imagePaths {
+		refs = append(refs, findImages(&doc, p)...)
+	}
+	if len(refs) == 0 {
+		if t.validateImages {
+			return nil, microerror.Maskf(validationFailedError, "no image references found in %#q at %s", file, strings.Join(t.imagePaths, ", "))
+		}
+		return values, nil
+	}
+
+	var edits []scalarEdit
+	var findings []string
+	for _, ref := range refs {
+		if t.rewriteRegistry && ref.Registry != t.registry {
+			edit, err := ref.rewriteRegistry(t.registry)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			edits = append(edits, edit)
+			ref.Registry = t.registry
+		}
+
+		if !t.validateImages {
+			continue
+		}
+
+		if t.registry != "" && ref.Registry != t.registry {
+			findings = append(findings, fmt.Sprintf("%s: registry is %#q, expected %#q", ref.Path, ref.Registry, t.registry))
+		}
+		if t.imageRepository != "" && ref.Repository != t.imageRepository {
+			findings = append(findings, fmt.Sprintf("%s: repository is %#q, expected %#q", ref.Path, ref.Repository, t.imageRepository))
+		}
+		// An empty tag usually means the chart's templates default it to
+		// the appVersion, which is checked separately.
+		if ref.Tag != "" && ref.Tag != buildInfo.Version && ref.Tag != buildInfo.AppVersion {
+			findings = append(findings, fmt.Sprintf("%s: tag is %#q, expected %#q", ref.Path, ref.Tag, buildInfo.Version))
+		}
+	}
+
+	if len(findings) > 0 {
+		return nil, microerror.Maskf(
+			validationFailedError,
+			"image references in %#q don't match the build:\n%s",
+			file, strings.Join(findings, "\n"),
+		)
+	}
+
+	values, err = applyScalarEdits(values, edits)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return values, nil
+}
+
+// findImages returns the image references at the dotted path p of doc. A `*`
+// segment matches every value of a mapping. Nothing is returned if the path
+// doesn't exist.
+func findImages(doc *yaml.Node, p string) []imageReference {
+	nodes := []*yaml.Node{doc}
+	paths := []string{""}
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) == 1 {
+		nodes = []*yaml.Node{doc.Content[0]}
+	}
+
+	for _, segment := range strings.Split(p, ".") {
+		var nextNodes []*yaml.Node
+		var nextPaths []string
+		for i, n := range nodes {
+			if n.Kind != yaml.MappingNode {
+				continue
+			}
+			for j := 0; j+1 < len(n.Content); j += 2 {
+				key := n.Content[j].Value
+				if segment == "*" || segment == key {
+					nextNodes = append(nextNodes, n.Content[j+1])
+					nextPaths = append(nextPaths, strings.TrimPrefix(paths[i]+"."+key, "."))
+				}
+			}
+		}
+		nodes, paths = nextNodes, nextPaths
+	}
+
+	var refs []imageReference
+	for i, n := range nodes {
+		switch n.Kind {
+		case yaml.ScalarNode:
+			ref := parseImageReference(n.Value)
+			ref.Path = paths[i]
+			ref.node = n
+			refs = append(refs, ref)
+		case yaml.MappingNode:
+			ref := imageReference{
+				Path: paths[i],
+				node: n,
+			}
+			for j := 0; j+1 < len(n.Content); j += 2 {
+				switch n.Content[j].Value {
+				case "registry":
+					ref.Registry = n.Content[j+1].Value
+				case "repository", "name":
+					ref.Repository = n.Content[j+1].Value
+				case "tag":
+					ref.Tag = n.Content[j+1].Value
+				}
+			}
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs
+}
+
+// parseImageReference splits an image reference such as
+// `gsoci.azurecr.io/giantswarm/app:1.2.3` into its registry, repository and
+// tag. Like Docker, the first path component is only taken as the registry if
+// it looks like a host name.
+func parseImageReference(s string) imageReference {
+	var ref imageReference
+
+	rest, _, _ := strings.Cut(s, "@")
+	if i := strings.IndexByte(rest, '/'); i > 0 {
+		host := rest[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry = host
+			rest = rest[i+1:]
+		}
+	}
+	if i := strings.LastIndexByte(rest, ':'); i > strings.LastIndexByte(rest, '/') {
+		ref.Tag = rest[i+1:]
+		rest = rest[:i]
+	}
+	ref.Repository = rest
+
+	return ref
+}
+
+// rewriteRegistry returns the edit setting the registry of ref.
+func (ref imageReference) rewriteRegistry(registry string) (scalarEdit, error) {
+	if ref.node.Kind == yaml.ScalarNode {
+		value := ref.node.Value
+		if ref.Registry != "" {
+			value = strings.TrimPrefix(value, ref.Registry+"/")
+		}
+		return scalarEdit{node: ref.node, value: registry + "/" + value}, nil
+	}
+
+	for j := 0; j+1 < len(ref.node.Content); j += 2 {
+		if ref.node.Content[j].Value == "registry" {
+			return scalarEdit{node: ref.node.Content[j+1], value: registry}, nil
+		}
+	}
+
+	return scalarEdit{}, microerror.Maskf(validationFailedError, "%s: can't rewrite registry, there is no registry key", ref.Path)
+}
+
+// scalarEdit replaces the value of a single line scalar.
+type scalarEdit struct {
+	node  *yaml.Node
+	value string
+}
+
+// applyScalarEdits applies edits to the YAML document data in place, keeping
+// its formatting and comments intact. Scalars keep their quoting style.
+func applyScalarEdits(data []byte, edits []scalarEdit) ([]byte, error) {
+	if len(edits) == 0 {
+		return data, nil
+	}
+
+	// Apply edits from the end so earlier positions stay valid.
+	sort.Slice(edits, func(a, b int) bool {
+		if edits[a].node.Line != edits[b].node.Line {
+			return edits[a].node.Line > edits[b].node.Line
+		}
+		return edits[a].node.Column > edits[b].node.Column
+	})
+
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	for _, e := range edits {
+		n := e.node
+		if n.Line < 1 || n.Line > len(lines) {
+			return nil, microerror.Maskf(validationFailedError, "can't rewrite %#q: invalid position", n.Value)
+		}
+		line := lines[n.Line-1]
+		start := n.Column - 1
+
+		var quote string
+		switch {
+		case n.Style&yaml.DoubleQuotedStyle != 0:
+			quote = `"`
+		case n.Style&yaml.SingleQuotedStyle != 0:
+			quote = `'`
+		case n.Style != 0:
+			return nil, microerror.Maskf(validationFailedError, "can't rewrite %#q at line %d: only plain and quoted scalars are supported", n.Value, n.Line)
+		}
+
+		raw := quote + n.Value + quote
+		if start < 0 || !bytes.HasPrefix(line[start:], []byte(raw)) {
+			return nil, microerror.Maskf(validationFailedError, "can't rewrite %#q at line %d: only single line scalars without escapes are supported", n.Value, n.Line)
+		}
+
+		var edited []byte
+		edited = append(edited, line[:start]...)
+		edited = append(edited, quote+e.value+quote...)
+		edited = append(edited, line[start+len(raw):]...)
+		lines[n.Line-1] = edited
+	}
+
+	return bytes.Join(lines, nil), nil
+}
@@ -0,0 +1,113 @@
+package helmtemplate
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+// TestCheckImages tests image references in values.yaml are checked against
+// the build and their registry rewritten.
+func TestCheckImages(t *testing.T) {
+	testCases := []struct {
+		name            string
+		values          string
+		imagePaths      []string
+		imageRepository string
+		rewriteRegistry bool
+		expectedValues  string
+		errorMatcher    func(err error) bool
+	}{
+		{
+			name:            "case 0: matching image mapping",
+			values:          "image:\n  registry: gsoci.azurecr.io\n  name: giantswarm/my-app\n  tag: \"[[ .Version ]]\"\n",
+			imageRepository: "giantswarm/my-app",
+			expectedValues:  "image:\n  registry: gsoci.azurecr.io\n  name: giantswarm/my-app\n  tag: \"1.2.3\"\n",
+		},
+		{
+			name:            "case 1: wrong registry, repository and tag",
+			values:          "image:\n  registry: quay.io\n  repository: giantswarm/other\n  tag: 1.0.0\n",
+			imageRepository: "giantswarm/my-app",
+			errorMatcher:    IsValidationFailedError,
+		},
+		{
+			name:            "case 2: rewrite registry keeping formatting",
+			values:          "# The image.\nimage:\n  registry: 'quay.io' # upstream\n  repository: giantswarm/my-app\n  tag: \"[[ .AppVersion ]]\"\nsidecar: quay.io/giantswarm/my-app:[[ .Version ]]\n",
+			imagePaths:      []string{"image", "sidecar"},
+			imageRepository: "giantswarm/my-app",
+			rewriteRegistry: true,
+			expectedValues:  "# The image.\nimage:\n  registry: 'gsoci.azurecr.io' # upstream\n  repository: giantswarm/my-app\n  tag: \"1.0.0\"\nsidecar: gsoci.azurecr.io/giantswarm/my-app:1.2.3\n",
+		},
+		{
+			name:            "case 3: image strings under wildcard path",
+			values:          "images:\n  app: gsoci.azurecr.io/giantswarm/my-app:[[ .Version ]]\n  job: docker.io/library/busybox:1.36\n",
+			imagePaths:      []string{"images.*"},
+			imageRepository: "giantswarm/my-app",
+			errorMatcher:    IsValidationFailedError,
+		},
+		{
+			name:         "case 4: no image reference found",
+			values:       "replicas: 1\n",
+			errorMatcher: IsValidationFailedError,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			config := Config{
+				Fs:              afero.NewMemMapFs(),
+				ChartDir:        "/chart",
+				Branch:          "master",
+				Sha:             "ea82e754178bb2b8065aca0a0760e77ce3733649",
+				Version:         "1.2.3",
+				AppVersion:      "1.0.0",
+				ValidateImages:  true,
+				Registry:        "gsoci.azurecr.io",
+				ImageRepository: tc.imageRepository,
+				ImagePaths:      tc.imagePaths,
+				RewriteRegistry: tc.rewriteRegistry,
+			}
+
+			err := setup(config, map[string]string{
+				HelmChartYamlName:  "name: my-app\nversion: [[ .Version ]]\n",
+				HelmValuesYamlName: tc.values,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error during setup: %v\n", err)
+			}
+
+			task, err := NewTemplateHelmChartTask(config)
+			if err != nil {
+				t.Fatalf("unexpected error when creating NewTemplateHelmChartTask: %v\n", err)
+			}
+
+			_, err = task.Run(false, false)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+			if err != nil {
+				return
+			}
+
+			values, err := afero.ReadFile(config.Fs, "/chart/"+HelmValuesYamlName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expectedValues, string(values)); diff != "" {
+				t.Errorf("values mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package helmtemplate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+)
+
+// journalVersion is the version of the journal format.
+const journalVersion = 1
+
+// journal records the original content of the files changed by templating a
+// chart, so that templating can be reverted.
+type journal struct {
+	Version int            `json:"version"`
+	Files   []journalEntry `json:"files"`
+}
+
+type journalEntry struct {
+	// Path is the path of the file relative to the chart directory.
+	Path string `json:"path"`
+	// Original is the content of the file before it was templated.
+	Original string `json:"original"`
+	// TemplatedSHA256 is the hex encoded sha256 checksum of the templated
+	// content, used to detect files edited after templating.
+	TemplatedSHA256 string `json:"templatedSha256"`
+}
+
+// JournalFile returns the path of the templating journal of the chart at
+// chartDir. It is kept inside the git directory of the repository containing
+// the chart, so it is neither committed nor packaged. Charts outside a git
+// repository can't be journaled.
+func JournalFile(chartDir string) (string, error) {
+	abs, err := filepath.Abs(chartDir)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	top, gitDir, err := findGitDir(abs)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	rel, err := filepath.Rel(top, abs)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return filepath.Join(gitDir, "architect", "template-journal", url.QueryEscape(filepath.ToSlash(rel))+".json"), nil
+}
+
+// findGitDir returns the top level directory of the git working tree
+// containing dir and its git directory. In worktrees and submodules `.git`
+// is a file pointing to the git directory, e.g.
+// `gitdir: ../.git/worktrees/my-branch`.
+func findGitDir(dir string) (string, string, error) {
+	for p := dir; ; p = filepath.Dir(p) {
+		dotGit := filepath.Join(p, ".git")
+		info, err := os.Stat(dotGit)
+		if os.IsNotExist(err) {
+			if filepath.Dir(p) == p {
+				return "", "", microerror.Maskf(invalidConfigError, "chart %#q is not inside a git repository, its templating can't be journaled", dir)
+			}
+			continue
+		} else if err != nil {
+			return "", "", microerror.Mask(err)
+		}
+
+		if info.IsDir() {
+			return p, dotGit, nil
+		}
+
+		data, err := os.ReadFile(dotGit)
+		if err != nil {
+			return "", "", microerror.Mask(err)
+		}
+		gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+		if !ok {
+			return "", "", microerror.Maskf(invalidConfigError, "%#q is neither a git directory nor a gitdir file", dotGit)
+		}
+		gitDir = strings.TrimSpace(gitDir)
+		if !filepath.IsAbs(gitDir) {
+			gitDir = filepath.Join(p, gitDir)
+		}
+
+		return p, filepath.Clean(gitDir), nil
+	}
+}
+
+// writeJournal records the original content of every file changed by
+// templating in the journal. When the chart has been templated before and
+// its files haven't been touched since, the content recorded back then is
+// kept, so the journal always leads back to the untemplated chart.
+func (t TemplateHelmChartTask) writeJournal(files []string, original, rendered [][]byte) error {
+	j, err := readJournal(t.fs, t.journalFile)
+	if IsNotTemplated(err) {
+		j = journal{Version: journalVersion}
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	entries := map[string]journalEntry{}
+	for _, e := range j.Files {
+		entries[e.Path] = e
+	}
+
+	for i, file := range files {
+		if bytes.Equal(original[i], rendered[i]) {
+			continue
+		}
+
+		e := journalEntry{
+			Path:            file,
+			Original:        string(original[i]),
+			TemplatedSHA256: sha256Hex(rendered[i]),
+		}
+		if previous, ok := entries[file]; ok && previous.TemplatedSHA256 == sha256Hex(original[i]) {
+			e.Original = previous.Original
+		}
+		entries[file] = e
+	}
+
+	j.Files = make([]journalEntry, 0, len(entries))
+	for _, e := range entries {
+		j.Files = append(j.Files, e)
+	}
+	sort.Slice(j.Files, func(a, b int) bool {
+		return j.Files[a].Path < j.Files[b].Path
+	})
+
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = t.fs.MkdirAll(filepath.Dir(t.journalFile), 0755)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	err = afero.WriteFile(t.fs, t.journalFile, data, permission)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Untemplate restores the files of the chart at chartDir to their content
+// before templating, as recorded in journalFile, and removes the journal. It
+// returns the restored paths, relative to chartDir. Nothing is restored if any
+// of the templated files has been changed since, as that change would be lost.
+func Untemplate(fs afero.Fs, chartDir, journalFile string) ([]string, error) {
+	j, err := readJournal(fs, journalFile)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var modified []string
+	for _, e := range j.Files {
+		current, err := afero.ReadFile(fs, path.Join(chartDir, e.Path))
+		if os.IsNotExist(err) {
+			modified = append(modified, e.Path+" (deleted)")
+			continue
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		if sha256Hex(current) != e.TemplatedSHA256 {
+			modified = append(modified, e.Path)
+		}
+	}
+	if len(modified) > 0 {
+		return nil, microerror.Maskf(
+			modifiedSinceTemplatingError,
+			"refusing to untemplate %#q, files changed since templating:\n%s",
+			chartDir, strings.Join(modified, "\n"),
+		)
+	}
+
+	restored := make([]string, 0, len(j.Files))
+	for _, e := range j.Files {
+		err := afero.WriteFile(fs, path.Join(chartDir, e.Path), []byte(e.Original), permission)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		restored = append(restored, e.Path)
+	}
+
+	err = fs.Remove(journalFile)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return restored, nil
+}
+
+// readJournal reads the journal at file. It returns a notTemplatedError if
+// there is none.
+func readJournal(fs afero.Fs, file string) (journal, error) {
+	data, err := afero.ReadFile(fs, file)
+	if os.IsNotExist(err) {
+		return journal{}, microerror.Maskf(notTemplatedError, "no templating journal found at %#q", file)
+	} else if err != nil {
+		return journal{}, microerror.Mask(err)
+	}
+
+	var j journal
+	err = json.Unmarshal(data, &j)
+	if err != nil {
+		return journal{}, microerror.Mask(err)
+	}
+	if j.Version != journalVersion {
+		return journal{}, microerror.Maskf(invalidConfigError, "templating journal %#q has unsupported version %d", file, j.Version)
+	}
+
+	return j, nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,197 @@
+package helmtemplate
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+// TestUntemplate tests templating a chart, possibly repeatedly, and reverting
+// it with the journal.
+func TestUntemplate(t *testing.T) {
+	files := map[string]string{
+		HelmChartYamlName:  "name: test\nversion: [[ .Version ]]\nappVersion: \"[[ .AppVersion ]]\"\n",
+		HelmValuesYamlName: "replicas: 1\n",
+	}
+
+	testCases := []struct {
+		name string
+		// runs are the versions the chart is templated with in turn.
+		runs []string
+		// edit is applied to the templated chart before untemplating.
+		edit             map[string]string
+		expectedRestored []string
+		errorMatcher     func(err error) bool
+	}{
+		{
+			name:             "case 0: revert templated chart",
+			runs:             []string{"1.2.3"},
+			expectedRestored: []string{HelmChartYamlName},
+		},
+		{
+			name:             "case 1: revert chart templated twice",
+			runs:             []string{"1.2.3", "1.2.3"},
+			expectedRestored: []string{HelmChartYamlName},
+		},
+		{
+			name: "case 2: refuse to revert chart edited after templating",
+			runs: []string{"1.2.3"},
+			edit: map[string]string{
+				HelmChartYamlName: "name: test\nversion: 1.2.4\nappVersion: \"1.0.0\"\n",
+			},
+			errorMatcher: IsModifiedSinceTemplating,
+		},
+		{
+			name:         "case 3: chart not templated",
+			errorMatcher: IsNotTemplated,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			config := Config{
+				Fs:          afero.NewMemMapFs(),
+				ChartDir:    "/repo/helm/test",
+				Branch:      "master",
+				Sha:         "ea82e754178bb2b8065aca0a0760e77ce3733649",
+				AppVersion:  "1.0.0",
+				JournalFile: "/repo/.git/architect/template-journal/helm%2Ftest.json",
+			}
+
+			err := setup(config, files)
+			if err != nil {
+				t.Fatalf("unexpected error during setup: %v\n", err)
+			}
+
+			for _, version := range tc.runs {
+				config.Version = version
+				task, err := NewTemplateHelmChartTask(config)
+				if err != nil {
+					t.Fatalf("unexpected error when creating NewTemplateHelmChartTask: %v\n", err)
+				}
+				_, err = task.Run(false, false)
+				if err != nil {
+					t.Fatalf("unexpected error during Run: %v\n", err)
+				}
+			}
+
+			for file, data := range tc.edit {
+				err := afero.WriteFile(config.Fs, config.ChartDir+"/"+file, []byte(data), permission)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			restored, err := Untemplate(config.Fs, config.ChartDir, config.JournalFile)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if err != nil {
+				// Nothing must have been reverted.
+				for file, data := range tc.edit {
+					current, err := afero.ReadFile(config.Fs, config.ChartDir+"/"+file)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if string(current) != data {
+						t.Errorf("edited file %#q was reverted", file)
+					}
+				}
+				return
+			}
+
+			if diff := cmp.Diff(tc.expectedRestored, restored); diff != "" {
+				t.Errorf("restored files mismatch (-want +got):\n%s", diff)
+			}
+
+			for file, data := range files {
+				current, err := afero.ReadFile(config.Fs, config.ChartDir+"/"+file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(data, string(current)); diff != "" {
+					t.Errorf("file %#q not restored (-want +got):\n%s", file, diff)
+				}
+			}
+
+			exists, err := afero.Exists(config.Fs, config.JournalFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exists {
+				t.Errorf("journal %#q not removed", config.JournalFile)
+			}
+		})
+	}
+}
+
+// TestJournalFile tests that journals are kept inside the git directory, also
+// when `.git` is a file as in worktrees, and that charts outside a git
+// repository can't be journaled.
+func TestJournalFile(t *testing.T) {
+	dir := t.TempDir()
+	chartDir := filepath.Join(dir, "helm", "my-app")
+
+	err := os.MkdirAll(chartDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = JournalFile(chartDir)
+	if !IsInvalidConfig(err) {
+		t.Fatalf("error == %#v, want matching", err)
+	}
+
+	_, err = git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := JournalFile(chartDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := filepath.Join(dir, ".git", "architect", "template-journal", "helm%2Fmy-app.json")
+	if file != expected {
+		t.Fatalf("journal file == %#q, want %#q", file, expected)
+	}
+
+	// A worktree of the repository, whose .git file points to its git
+	// directory relative to the worktree.
+	worktree := filepath.Join(dir, "worktrees", "my-branch")
+	err = os.MkdirAll(filepath.Join(worktree, "helm", "my-app"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: ../../.git/worktrees/my-branch\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err = JournalFile(filepath.Join(worktree, "helm", "my-app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = filepath.Join(dir, ".git", "worktrees", "my-branch", "architect", "template-journal", "helm%2Fmy-app.json")
+	if file != expected {
+		t.Fatalf("journal file == %#q, want %#q", file, expected)
+	}
+}
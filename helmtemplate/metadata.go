@@ -0,0 +1,144 @@
+package helmtemplate
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/giantswarm/microerror"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"sigs.k8s.io/yaml"
+
+	"github.com/giantswarm/architect/v2/schemacheck"
+)
+
+//go:embed schema/gs_metadata_chart_schema.yaml
+var gsMetadataChartSchema []byte
+
+// metadataSchemaURL is the URL the embedded schema is registered under. It is
+// never fetched.
+const metadataSchemaURL = "https://schemas.giantswarm.io/gs_metadata_chart_schema.json"
+
+// untemplatedFieldRegexp matches top-level `version` and `appVersion` fields
+// of an untemplated Chart.yaml, e.g. `version: [[ .Version ]]`, which YAML
+// would otherwise read as a nested list.
+var untemplatedFieldRegexp = regexp.MustCompile(`(?m)^((?:version|appVersion):[ \t]*)(\[\[[^'\n]*\]\])[ \t]*$`)
+
+// placeholderRegexp matches a value which is an unrendered `[[ ]]` action.
+var placeholderRegexp = regexp.MustCompile(`^\[\[.*\]\]$`)
+
+// MetadataFinding is a single violation of Giant Swarm's chart metadata
+// conventions found in Chart.yaml.
+type MetadataFinding struct {
+	// Field is the JSON pointer of the offending Chart.yaml field.
+	Field string `json:"field"`
+	// Message describes the violation.
+	Message string `json:"message"`
+}
+
+func (f MetadataFinding) String() string {
+	return fmt.Sprintf("%#q: %s", f.Field, f.Message)
+}
+
+// LintMetadata checks the Chart.yaml content of the chart in chartDir
+// against the embedded Giant Swarm chart metadata schema, and additionally
+// makes sure the chart is named after its directory and its version is a
+// valid semantic version. An unrendered `[[ ]]` placeholder, as in the
+// Chart.yaml of the repository before `helm template`, is accepted as the
+// version or app version. Findings are sorted by field.
+func LintMetadata(chartDir string, chartYaml []byte) ([]MetadataFinding, error) {
+	var schema *jsonschema.Schema
+	{
+		data, err := yaml.YAMLToJSON(gsMetadataChartSchema)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		c := jsonschema.NewCompiler()
+		err = c.AddResource(metadataSchemaURL, doc)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		schema, err = c.Compile(metadataSchemaURL)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var instance interface{}
+	{
+		chartYaml = untemplatedFieldRegexp.ReplaceAll(chartYaml, []byte("$1'$2'"))
+		data, err := yaml.YAMLToJSON(chartYaml)
+		if err != nil {
+			return nil, microerror.Maskf(validationFailedError, "invalid %s: %s", HelmChartYamlName, err)
+		}
+		instance, err = jsonschema.UnmarshalJSON(bytes.NewReader(data))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var findings []MetadataFinding
+
+	err := schema.Validate(instance)
+	if validationErr, ok := err.(*jsonschema.ValidationError); ok {
+		for _, v := range schemacheck.Violations(validationErr) {
+			findings = append(findings, MetadataFinding{Field: v.Pointer, Message: v.Message})
+		}
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if m, ok := instance.(map[string]interface{}); ok {
+		dir := filepath.Base(filepath.Clean(chartDir))
+		if name, ok := m["name"].(string); ok && name != dir {
+			findings = append(findings, MetadataFinding{
+				Field:   "/name",
+				Message: fmt.Sprintf("chart name %#q must match its directory name %#q", name, dir),
+			})
+		}
+
+		if version, ok := m["version"].(string); ok && !placeholderRegexp.MatchString(version) {
+			if _, err := semver.StrictNewVersion(version); err != nil {
+				findings = append(findings, MetadataFinding{
+					Field:   "/version",
+					Message: fmt.Sprintf("%#q is not a valid semantic version", version),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Field < findings[j].Field
+	})
+
+	return findings, nil
+}
+
+// lintMetadataError turns findings into a validationFailedError, or returns
+// nil when there are none.
+func lintMetadataError(file string, findings []MetadataFinding) error {
+	if len(findings) == 0 {
+		return nil
+	}
+
+	lines := make([]string, 0, len(findings))
+	for _, f := range findings {
+		lines = append(lines, fmt.Sprintf("%s: %s", file, f))
+	}
+
+	return microerror.Maskf(
+		validationFailedError,
+		"chart metadata doesn't follow Giant Swarm conventions:\n%s",
+		strings.Join(lines, "\n"),
+	)
+}
@@ -0,0 +1,103 @@
+package helmtemplate
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestLintMetadata tests Chart.yaml is checked against Giant Swarm chart
+// metadata conventions.
+func TestLintMetadata(t *testing.T) {
+	testCases := []struct {
+		name             string
+		chartDir         string
+		chartYaml        string
+		expectedFindings []MetadataFinding
+	}{
+		{
+			name:     "case 0: valid metadata",
+			chartDir: "helm/my-app",
+			chartYaml: `apiVersion: v2
+name: my-app
+version: 1.2.3
+appVersion: 1.2.3
+annotations:
+  application.giantswarm.io/team: honeybadger
+  config.giantswarm.io/version: 1.x.x
+restrictions:
+  clusterSingleton: true
+`,
+		},
+		{
+			name:     "case 1: every convention violated",
+			chartDir: "helm/my-app",
+			chartYaml: `apiVersion: v1
+name: other-app
+version: v1.2
+annotations:
+  application.giantswarm.io/team: ""
+restrictions:
+  singleton: true
+`,
+			expectedFindings: []MetadataFinding{
+				{Field: "/annotations", Message: "missing property 'config.giantswarm.io/version'"},
+				{Field: "/annotations/application.giantswarm.io~1team", Message: "minLength: got 0, want 1"},
+				{Field: "/apiVersion", Message: "value must be 'v2'"},
+				{Field: "/name", Message: "chart name `other-app` must match its directory name `my-app`"},
+				{Field: "/restrictions", Message: "additional properties 'singleton' not allowed"},
+				{Field: "/version", Message: "`v1.2` is not a valid semantic version"},
+			},
+		},
+		{
+			name:      "case 2: missing required fields",
+			chartDir:  "my-app",
+			chartYaml: "name: my-app\n",
+			expectedFindings: []MetadataFinding{
+				{Field: "", Message: "missing properties 'apiVersion', 'version', 'annotations'"},
+			},
+		},
+		{
+			name:     "case 3: untemplated version placeholders",
+			chartDir: "helm/my-app",
+			chartYaml: `apiVersion: v2
+name: my-app
+version: [[ .Version ]]
+appVersion: "[[ .AppVersion ]]"
+annotations:
+  application.giantswarm.io/team: honeybadger
+  config.giantswarm.io/version: 1.x.x
+`,
+		},
+		{
+			name:     "case 4: placeholder in the middle of the version",
+			chartDir: "helm/my-app",
+			chartYaml: `apiVersion: v2
+name: my-app
+version: 1.2.3-[[ .SHA ]]
+annotations:
+  application.giantswarm.io/team: honeybadger
+  config.giantswarm.io/version: 1.x.x
+`,
+			expectedFindings: []MetadataFinding{
+				{Field: "/version", Message: "`1.2.3-[[ .SHA ]]` is not a valid semantic version"},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			findings, err := LintMetadata(tc.chartDir, []byte(tc.chartYaml))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expectedFindings, findings); diff != "" {
+				t.Errorf("findings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
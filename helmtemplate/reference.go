@@ -0,0 +1,102 @@
+package helmtemplate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// unresolvedReferences statically walks tmpl and returns a "file:line:col"
+// prefixed description of every field reference rooted at the build info
+// which can't be resolved against buildInfo. References inside `with` and
+// `range` bodies are relative to a different dot and therefore not checked.
+func unresolvedReferences(tmpl *template.Template, buildInfo BuildInfo) []string {
+	if tmpl.Tree == nil || tmpl.Root == nil {
+		return nil
+	}
+
+	w := referenceWalker{
+		tree:      tmpl.Tree,
+		buildInfo: buildInfo,
+	}
+	w.walk(tmpl.Root, true)
+
+	return w.unresolved
+}
+
+type referenceWalker struct {
+	tree       *parse.Tree
+	buildInfo  BuildInfo
+	unresolved []string
+}
+
+func (w *referenceWalker) walk(node parse.Node, rootDot bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			w.walk(c, rootDot)
+		}
+	case *parse.ActionNode:
+		w.walk(n.Pipe, rootDot)
+	case *parse.IfNode:
+		w.walk(n.Pipe, rootDot)
+		w.walk(n.List, rootDot)
+		w.walk(n.ElseList, rootDot)
+	case *parse.RangeNode:
+		w.walk(n.Pipe, rootDot)
+		w.walk(n.List, false)
+		w.walk(n.ElseList, rootDot)
+	case *parse.WithNode:
+		w.walk(n.Pipe, rootDot)
+		w.walk(n.List, false)
+		w.walk(n.ElseList, rootDot)
+	case *parse.TemplateNode:
+		w.walk(n.Pipe, rootDot)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			w.walk(c, rootDot)
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			w.walk(a, rootDot)
+		}
+	case *parse.ChainNode:
+		w.walk(n.Node, rootDot)
+	case *parse.FieldNode:
+		if rootDot {
+			w.check(n, n.Ident)
+		}
+	}
+}
+
+func (w *referenceWalker) check(n parse.Node, ident []string) {
+	field, ok := reflect.TypeOf(w.buildInfo).FieldByName(ident[0])
+	if !ok {
+		w.report(n, fmt.Sprintf("build info has no field %#q", ident[0]))
+		return
+	}
+
+	if field.Type.Kind() == reflect.Map && len(ident) > 1 {
+		if _, ok := w.buildInfo.Extra[ident[1]]; !ok {
+			w.report(n, fmt.Sprintf("extra build info has no key %#q", ident[1]))
+		}
+		return
+	}
+
+	if len(ident) > 1 {
+		w.report(n, fmt.Sprintf("build info field %#q has no field %#q", ident[0], ident[1]))
+	}
+}
+
+func (w *referenceWalker) report(n parse.Node, msg string) {
+	location, context := w.tree.ErrorContext(n)
+	w.unresolved = append(w.unresolved, fmt.Sprintf("%s: %s in %s", location, msg, strings.TrimSpace(context)))
+}
@@ -0,0 +1,92 @@
+package helmtemplate
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/yaml"
+
+	"github.com/giantswarm/architect/v2/schemacheck"
+)
+
+// HelmValuesSchemaName is the name of the JSON schema file for values.yaml.
+const HelmValuesSchemaName = "values.schema.json"
+
+// validateValues validates the rendered values.yaml at file against the
+// values.schema.json next to it, if there is one. Schemas without `$schema`
+// are treated as draft 7. Only local `$ref`s are resolved, so validation never
+// hits the network. Every violation is reported with its JSON pointer.
+func (t TemplateHelmChartTask) validateValues(file string, values []byte) error {
+	schemaPath := path.Join(t.chartDir, path.Dir(file), HelmValuesSchemaName)
+
+	exists, err := afero.Exists(t.fs, schemaPath)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if !exists {
+		return nil
+	}
+
+	schemaURL, err := schemacheck.FileURL(schemaPath)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var schema *jsonschema.Schema
+	{
+		c := jsonschema.NewCompiler()
+		c.DefaultDraft(jsonschema.Draft7)
+		c.UseLoader(jsonschema.SchemeURLLoader{
+			"file": schemacheck.Loader{Fs: t.fs},
+		})
+
+		schema, err = c.Compile(schemaURL)
+		if err != nil {
+			return microerror.Maskf(validationFailedError, "invalid schema %#q: %s", path.Join(path.Dir(file), HelmValuesSchemaName), err)
+		}
+	}
+
+	var instance interface{}
+	{
+		data, err := yaml.YAMLToJSON(values)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		instance, err = jsonschema.UnmarshalJSON(bytes.NewReader(data))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		// Helm treats an empty values.yaml as an empty map.
+		if instance == nil {
+			instance = map[string]interface{}{}
+		}
+	}
+
+	err = schema.Validate(instance)
+	if err == nil {
+		return nil
+	}
+
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return microerror.Mask(err)
+	}
+
+	var violations []string
+	for _, v := range schemacheck.Violations(validationErr) {
+		violations = append(violations, fmt.Sprintf("%s: %#q: %s", file, v.Pointer, v.Message))
+	}
+
+	return microerror.Maskf(
+		validationFailedError,
+		"values don't match %s:\n%s",
+		HelmValuesSchemaName, strings.Join(violations, "\n"),
+	)
+}
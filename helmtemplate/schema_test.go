@@ -0,0 +1,109 @@
+package helmtemplate
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+)
+
+// TestValidateValues tests validation of rendered values against
+// values.schema.json.
+func TestValidateValues(t *testing.T) {
+	schema := `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "properties": {
+    "image": {"$ref": "definitions.json#/image"},
+    "replicas": {"type": "integer", "minimum": 1}
+  },
+  "additionalProperties": false
+}`
+	definitions := `{
+  "image": {
+    "type": "object",
+    "required": ["tag"],
+    "properties": {
+      "registry": {"type": "string"},
+      "tag": {"type": "string"}
+    }
+  }
+}`
+
+	testCases := []struct {
+		name               string
+		values             string
+		schema             string
+		expectedViolations []string
+	}{
+		{
+			name:   "case 0: valid values",
+			values: "image:\n  tag: \"1.2.3\"\nreplicas: 2\n",
+			schema: schema,
+		},
+		{
+			name:   "case 1: every violation is reported with its JSON pointer",
+			values: "image:\n  registry: 1\nreplicas: 0\nfoo/bar: true\n",
+			schema: schema,
+			expectedViolations: []string{
+				"values.yaml: ``: additional properties 'foo/bar' not allowed",
+				"values.yaml: `/image`: missing property 'tag'",
+				"values.yaml: `/image/registry`: got number, want string",
+				"values.yaml: `/replicas`: minimum: got 0, want 1",
+			},
+		},
+		{
+			name:   "case 2: empty values",
+			values: "",
+			schema: `{"type": "object"}`,
+		},
+		{
+			name:   "case 3: no schema",
+			values: "replicas: foo\n",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			fs := afero.NewMemMapFs()
+			if tc.schema != "" {
+				err := afero.WriteFile(fs, "/chart/"+HelmValuesSchemaName, []byte(tc.schema), permission)
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = afero.WriteFile(fs, "/chart/definitions.json", []byte(definitions), permission)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			task := TemplateHelmChartTask{
+				fs:       fs,
+				chartDir: "/chart",
+			}
+
+			err := task.validateValues(HelmValuesYamlName, []byte(tc.values))
+			if len(tc.expectedViolations) == 0 {
+				if err != nil {
+					t.Fatalf("error == %#v, want nil", err)
+				}
+				return
+			}
+
+			if !IsValidationFailedError(err) {
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			msg := microerror.Pretty(err, false)
+			for _, v := range tc.expectedViolations {
+				if !strings.Contains(msg, v) {
+					t.Errorf("expected violation %#q in:\n%s", v, msg)
+				}
+			}
+		})
+	}
+}
@@ -18,9 +18,29 @@ type BuildInfo struct {
 	SHA string
 	// Version is the version of the commit being built.
 	Version string
-	// AppVersion is the version read from pkg/project/project.go if it
-	// exists or set to the same value as Version otherwise.
+	// AppVersion is the version read from the repository's app version
+	// sources, pkg/project/project.go by default, if found or set to the same
+	// value as Version otherwise.
 	AppVersion string
+	// Extra holds user defined build information, e.g. build timestamp or
+	// CI pipeline URL. Values are accessible as `[[ .Extra.key ]]`.
+	Extra map[string]string
+}
+
+// Result describes a templated chart.
+type Result struct {
+	// BuildInfo is the build information the chart was templated with.
+	BuildInfo BuildInfo
+	// ModifiedFiles are the files whose content changed, in templating order.
+	ModifiedFiles []TemplatedFile
+}
+
+// TemplatedFile describes a file written by templating.
+type TemplatedFile struct {
+	// Path is the path of the file relative to the chart directory.
+	Path string `json:"path"`
+	// SHA256 is the hex encoded sha256 checksum of the templated content.
+	SHA256 string `json:"sha256"`
 }
 
 // renderedChart is used for chart validation after it has been filled with
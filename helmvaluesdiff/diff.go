@@ -0,0 +1,244 @@
+package helmvaluesdiff
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Types of changes.
+const (
+	ChangeAdded          = "added"
+	ChangeRemoved        = "removed"
+	ChangeTypeChanged    = "type-changed"
+	ChangeDefaultChanged = "default-changed"
+)
+
+// Change is a single difference between the values of two chart versions.
+type Change struct {
+	// File is the file the change was found in, values.yaml or
+	// values.schema.json.
+	File string `json:"file"`
+	// Path is the dotted path of the value, e.g. `image.tag`. Keys
+	// containing dots are quoted.
+	Path string `json:"path"`
+	Type string `json:"type"`
+	// Breaking is set for changes which can break releases setting the
+	// value: removals, and type changes unless the new schema still accepts
+	// every previously accepted type.
+	Breaking bool `json:"breaking"`
+
+	OldType string `json:"oldType,omitempty"`
+	NewType string `json:"newType,omitempty"`
+	// Old and New are the default values, if any.
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// diffValues compares two values.yaml documents. Objects are compared key by
+// key; any other value, including arrays, as a whole. A value which was or
+// becomes null is considered unset, so changing it is a default change.
+func diffValues(from, to map[string]interface{}) []Change {
+	var changes []Change
+
+	var walk func(prefix []string, from, to map[string]interface{})
+	walk = func(prefix []string, from, to map[string]interface{}) {
+		for _, key := range unionKeys(from, to) {
+			p := append(append([]string{}, prefix...), key)
+			oldValue, inFrom := from[key]
+			newValue, inTo := to[key]
+
+			c := Change{
+				File:    HelmValuesYamlName,
+				Path:    formatPath(p),
+				OldType: valueType(oldValue),
+				NewType: valueType(newValue),
+				Old:     oldValue,
+				New:     newValue,
+			}
+
+			switch {
+			case !inTo:
+				c.Type = ChangeRemoved
+				c.Breaking = true
+				c.NewType = ""
+			case !inFrom:
+				c.Type = ChangeAdded
+				c.OldType = ""
+			case c.OldType == "object" && c.NewType == "object":
+				walk(p, oldValue.(map[string]interface{}), newValue.(map[string]interface{}))
+				continue
+			case c.OldType != c.NewType && c.OldType != "null" && c.NewType != "null":
+				c.Type = ChangeTypeChanged
+				c.Breaking = true
+			case !reflect.DeepEqual(oldValue, newValue):
+				c.Type = ChangeDefaultChanged
+				c.OldType = ""
+				c.NewType = ""
+			default:
+				continue
+			}
+
+			changes = append(changes, c)
+		}
+	}
+	walk(nil, from, to)
+
+	return changes
+}
+
+// diffSchemas compares the properties of two values.schema.json documents,
+// recursing into nested `properties`. A schema which isn't present is
+// treated as accepting anything, so adding or removing the whole schema isn't
+// reported.
+func diffSchemas(from, to map[string]interface{}) []Change {
+	if from == nil || to == nil {
+		return nil
+	}
+
+	var changes []Change
+
+	var walk func(prefix []string, from, to map[string]interface{})
+	walk = func(prefix []string, from, to map[string]interface{}) {
+		fromProperties, _ := from["properties"].(map[string]interface{})
+		toProperties, _ := to["properties"].(map[string]interface{})
+
+		for _, key := range unionKeys(fromProperties, toProperties) {
+			p := append(append([]string{}, prefix...), key)
+			oldSchema, inFrom := fromProperties[key].(map[string]interface{})
+			newSchema, inTo := toProperties[key].(map[string]interface{})
+
+			c := Change{
+				File: HelmValuesSchema,
+				Path: formatPath(p),
+			}
+
+			switch {
+			case !inTo:
+				c.Type = ChangeRemoved
+				c.Breaking = true
+				c.OldType = strings.Join(schemaTypes(oldSchema), "|")
+				changes = append(changes, c)
+				continue
+			case !inFrom:
+				c.Type = ChangeAdded
+				c.NewType = strings.Join(schemaTypes(newSchema), "|")
+				changes = append(changes, c)
+				continue
+			}
+
+			oldTypes, newTypes := schemaTypes(oldSchema), schemaTypes(newSchema)
+			if !reflect.DeepEqual(oldTypes, newTypes) {
+				c.Type = ChangeTypeChanged
+				c.Breaking = !accepts(newTypes, oldTypes)
+				c.OldType = strings.Join(oldTypes, "|")
+				c.NewType = strings.Join(newTypes, "|")
+				changes = append(changes, c)
+			} else if !reflect.DeepEqual(oldSchema["default"], newSchema["default"]) {
+				c.Type = ChangeDefaultChanged
+				c.Old = oldSchema["default"]
+				c.New = newSchema["default"]
+				changes = append(changes, c)
+			}
+
+			walk(p, oldSchema, newSchema)
+		}
+	}
+	walk(nil, from, to)
+
+	return changes
+}
+
+// schemaTypes returns the sorted `type`s of schema. A schema without type
+// accepts any type and has none.
+func schemaTypes(schema map[string]interface{}) []string {
+	var types []string
+	switch t := schema["type"].(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// accepts returns whether a schema of newTypes accepts every value a schema
+// of oldTypes accepts.
+func accepts(newTypes, oldTypes []string) bool {
+	if len(newTypes) == 0 {
+		return true
+	}
+	if len(oldTypes) == 0 {
+		return false
+	}
+
+	for _, o := range oldTypes {
+		ok := false
+		for _, n := range newTypes {
+			// Integers are numbers.
+			if n == o || (n == "number" && o == "integer") {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// valueType returns the JSON type of a value decoded from YAML.
+func valueType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64, int64, int:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return reflect.TypeOf(v).String()
+	}
+}
+
+func unionKeys(a, b map[string]interface{}) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range []map[string]interface{}{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func formatPath(p []string) string {
+	quoted := make([]string, len(p))
+	for i, k := range p {
+		if k == "" || strings.ContainsAny(k, ". \"") {
+			k = `"` + strings.ReplaceAll(k, `"`, `\"`) + `"`
+		}
+		quoted[i] = k
+	}
+
+	return strings.Join(quoted, ".")
+}
@@ -0,0 +1,140 @@
+package helmvaluesdiff
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/yaml"
+)
+
+func Test_diffValues(t *testing.T) {
+	testCases := []struct {
+		name            string
+		from            string
+		to              string
+		expectedChanges []Change
+	}{
+		{
+			name: "case 0: no changes",
+			from: "image:\n  tag: 1.0.0\nreplicas: 1\n",
+			to:   "replicas: 1\nimage:\n  tag: 1.0.0\n",
+		},
+		{
+			name: "case 1: added, removed and changed values",
+			from: "image:\n  tag: 1.0.0\n  pullPolicy: IfNotPresent\nreplicas: 1\nports: [80]\nannotations:\n  app.kubernetes.io/name: a\n",
+			to:   "image:\n  tag: 2.0.0\n  digest: \"\"\nreplicas: \"1\"\nports: [80, 443]\nannotations:\n  app.kubernetes.io/name: b\n",
+			expectedChanges: []Change{
+				{File: "values.yaml", Path: `annotations."app.kubernetes.io/name"`, Type: ChangeDefaultChanged, Old: "a", New: "b"},
+				{File: "values.yaml", Path: "image.digest", Type: ChangeAdded, NewType: "string", New: ""},
+				{File: "values.yaml", Path: "image.pullPolicy", Type: ChangeRemoved, Breaking: true, OldType: "string", Old: "IfNotPresent"},
+				{File: "values.yaml", Path: "image.tag", Type: ChangeDefaultChanged, Old: "1.0.0", New: "2.0.0"},
+				{File: "values.yaml", Path: "ports", Type: ChangeDefaultChanged, Old: []interface{}{float64(80)}, New: []interface{}{float64(80), float64(443)}},
+				{File: "values.yaml", Path: "replicas", Type: ChangeTypeChanged, Breaking: true, OldType: "number", NewType: "string", Old: float64(1), New: "1"},
+			},
+		},
+		{
+			name: "case 2: null values",
+			from: "resources: null\nnodeSelector: {}\n",
+			to:   "resources:\n  limits: {}\nnodeSelector: null\n",
+			expectedChanges: []Change{
+				{File: "values.yaml", Path: "nodeSelector", Type: ChangeDefaultChanged, Old: map[string]interface{}{}},
+				{File: "values.yaml", Path: "resources", Type: ChangeDefaultChanged, New: map[string]interface{}{"limits": map[string]interface{}{}}},
+			},
+		},
+		{
+			name: "case 3: object replaced with scalar",
+			from: "proxy:\n  enabled: true\n",
+			to:   "proxy: true\n",
+			expectedChanges: []Change{
+				{File: "values.yaml", Path: "proxy", Type: ChangeTypeChanged, Breaking: true, OldType: "object", NewType: "boolean", Old: map[string]interface{}{"enabled": true}, New: true},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			var from, to map[string]interface{}
+			err := yaml.Unmarshal([]byte(tc.from), &from)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = yaml.Unmarshal([]byte(tc.to), &to)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			changes := diffValues(from, to)
+			if diff := cmp.Diff(tc.expectedChanges, changes); diff != "" {
+				t.Errorf("changes mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_diffSchemas(t *testing.T) {
+	testCases := []struct {
+		name            string
+		from            string
+		to              string
+		expectedChanges []Change
+	}{
+		{
+			name: "case 0: schema added",
+			to:   `{"properties": {"replicas": {"type": "integer"}}}`,
+		},
+		{
+			name: "case 1: properties changed",
+			from: `{"properties": {
+				"replicas": {"type": "integer"},
+				"port": {"type": "integer"},
+				"image": {"type": "object", "properties": {"tag": {"type": "string"}, "pullPolicy": {"type": "string", "default": "Always"}}},
+				"legacy": {"type": "boolean"},
+				"any": {}
+			}}`,
+			to: `{"properties": {
+				"replicas": {"type": ["integer", "string"]},
+				"port": {"type": "number"},
+				"image": {"type": "object", "properties": {"tag": {"type": "integer"}, "pullPolicy": {"type": "string", "default": "IfNotPresent"}}},
+				"any": {"type": "string"},
+				"extra": {"type": "string"}
+			}}`,
+			expectedChanges: []Change{
+				{File: "values.schema.json", Path: "any", Type: ChangeTypeChanged, Breaking: true, NewType: "string"},
+				{File: "values.schema.json", Path: "extra", Type: ChangeAdded, NewType: "string"},
+				{File: "values.schema.json", Path: "image.pullPolicy", Type: ChangeDefaultChanged, Old: "Always", New: "IfNotPresent"},
+				{File: "values.schema.json", Path: "image.tag", Type: ChangeTypeChanged, Breaking: true, OldType: "string", NewType: "integer"},
+				{File: "values.schema.json", Path: "legacy", Type: ChangeRemoved, Breaking: true, OldType: "boolean"},
+				{File: "values.schema.json", Path: "port", Type: ChangeTypeChanged, OldType: "integer", NewType: "number"},
+				{File: "values.schema.json", Path: "replicas", Type: ChangeTypeChanged, OldType: "integer", NewType: "integer|string"},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			var from, to map[string]interface{}
+			if tc.from != "" {
+				err := yaml.Unmarshal([]byte(tc.from), &from)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.to != "" {
+				err := yaml.Unmarshal([]byte(tc.to), &to)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			changes := diffSchemas(from, to)
+			if diff := cmp.Diff(tc.expectedChanges, changes); diff != "" {
+				t.Errorf("changes mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package helmvaluesdiff
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var invalidChartError = &microerror.Error{
+	Kind: "invalidChartError",
+}
+
+// IsInvalidChart asserts invalidChartError.
+func IsInvalidChart(err error) bool {
+	return microerror.Cause(err) == invalidChartError
+}
@@ -0,0 +1,153 @@
+package helmvaluesdiff
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/giantswarm/microerror"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	HelmChartYamlName  = "Chart.yaml"
+	HelmValuesYamlName = "values.yaml"
+	HelmValuesSchema   = "values.schema.json"
+)
+
+// chart holds the parts of a chart version which are compared.
+type chart struct {
+	// version is the version in Chart.yaml.
+	version string
+	values  map[string]interface{}
+	// schema is nil if the chart has no values.schema.json.
+	schema map[string]interface{}
+}
+
+// readFileFunc returns the content of the named chart file, or nil if it
+// doesn't exist.
+type readFileFunc func(name string) ([]byte, error)
+
+// loadDir loads the chart in dir.
+func loadDir(fs afero.Fs, dir string) (chart, error) {
+	return load(dir, func(name string) ([]byte, error) {
+		data, err := afero.ReadFile(fs, filepath.Join(dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		return data, nil
+	})
+}
+
+// loadRef loads the chart in dir, inside a git repository, as of the commit
+// ref resolves to.
+func loadRef(dir, ref string) (chart, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return chart{}, microerror.Mask(err)
+	}
+
+	repo, err := git.PlainOpenWithOptions(abs, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return chart{}, microerror.Maskf(invalidConfigError, "%#q is not inside a git repository: %s", dir, err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return chart{}, microerror.Mask(err)
+	}
+	rel, err := filepath.Rel(wt.Filesystem.Root(), abs)
+	if err != nil {
+		return chart{}, microerror.Mask(err)
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return chart{}, microerror.Maskf(invalidConfigError, "resolving git ref %#q: %s", ref, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return chart{}, microerror.Mask(err)
+	}
+
+	return load(dir+"@"+ref, func(name string) ([]byte, error) {
+		f, err := commit.File(path.Join(filepath.ToSlash(rel), name))
+		if errors.Is(err, object.ErrFileNotFound) {
+			return nil, nil
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		content, err := f.Contents()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		return []byte(content), nil
+	})
+}
+
+// load loads a chart from the files returned by readFile. location
+// identifies the chart in errors.
+func load(location string, readFile readFileFunc) (chart, error) {
+	var c chart
+
+	{
+		data, err := readFile(HelmChartYamlName)
+		if err != nil {
+			return chart{}, microerror.Mask(err)
+		}
+		if data == nil {
+			return chart{}, microerror.Maskf(invalidChartError, "%#q has no %s", location, HelmChartYamlName)
+		}
+
+		// The version of a chart which isn't templated yet is a
+		// placeholder such as `[[ .Version ]]`, which YAML parses as a
+		// nested sequence. It is left empty then, and the version bump is
+		// unknown unless the version is overridden.
+		var metadata struct {
+			Version interface{} `json:"version"`
+		}
+		err = yaml.Unmarshal(data, &metadata)
+		if err != nil {
+			return chart{}, microerror.Maskf(invalidChartError, "parsing %s of %#q: %s", HelmChartYamlName, location, err)
+		}
+		if v, ok := metadata.Version.(string); ok {
+			c.version = v
+		}
+	}
+
+	{
+		data, err := readFile(HelmValuesYamlName)
+		if err != nil {
+			return chart{}, microerror.Mask(err)
+		}
+		err = yaml.Unmarshal(data, &c.values)
+		if err != nil {
+			return chart{}, microerror.Maskf(invalidChartError, "parsing %s of %#q: %s", HelmValuesYamlName, location, err)
+		}
+		if c.values == nil {
+			c.values = map[string]interface{}{}
+		}
+	}
+
+	{
+		data, err := readFile(HelmValuesSchema)
+		if err != nil {
+			return chart{}, microerror.Mask(err)
+		}
+		if data != nil {
+			err = yaml.Unmarshal(data, &c.schema)
+			if err != nil {
+				return chart{}, microerror.Maskf(invalidChartError, "parsing %s of %#q: %s", HelmValuesSchema, location, err)
+			}
+		}
+	}
+
+	return c, nil
+}
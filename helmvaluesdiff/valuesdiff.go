@@ -0,0 +1,182 @@
+// Package helmvaluesdiff compares the values of two versions of a helm chart
+// to find changes which can break releases customising them.
+package helmvaluesdiff
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+)
+
+// Version bumps between the compared chart versions.
+const (
+	BumpMajor   = "major"
+	BumpMinor   = "minor"
+	BumpPatch   = "patch"
+	BumpNone    = "none"
+	BumpUnknown = "unknown"
+)
+
+// ValuesDiffTask is used to run a diff-helm-chart-values command.
+type ValuesDiffTask struct {
+	fs afero.Fs
+
+	fromDir     string
+	fromRef     string
+	fromVersion string
+	toDir       string
+	toRef       string
+	toVersion   string
+}
+
+// Config holds configuration for building a new ValuesDiffTask. Each side of
+// the comparison is a chart directory, read from the filesystem or, if the
+// ref is set, from the git repository containing it as of that ref.
+type Config struct {
+	Fs afero.Fs
+
+	FromDir string
+	FromRef string
+	// FromVersion overrides the version in the Chart.yaml of the old chart,
+	// e.g. when it is a `[[ .Version ]]` placeholder.
+	FromVersion string
+
+	ToDir string
+	ToRef string
+	// ToVersion overrides the version in the Chart.yaml of the new chart.
+	ToVersion string
+}
+
+// Result is the outcome of comparing two chart versions.
+type Result struct {
+	FromVersion string `json:"fromVersion"`
+	ToVersion   string `json:"toVersion"`
+	// Bump is the version bump between the two versions. For 0.x versions a
+	// minor bump is considered major, as they may break compatibility.
+	Bump    string   `json:"bump"`
+	Changes []Change `json:"changes"`
+	// Breaking is set if any change is breaking.
+	Breaking bool `json:"breaking"`
+}
+
+// Allowed returns whether the changes are compatible with the version bump,
+// i.e. there are no breaking changes or the bump is major.
+func (r Result) Allowed() bool {
+	return !r.Breaking || r.Bump == BumpMajor
+}
+
+// Run compares the values.yaml, and values.schema.json if both charts have
+// one, of the two charts.
+func (t *ValuesDiffTask) Run() (Result, error) {
+	from, err := t.load(t.fromDir, t.fromRef)
+	if err != nil {
+		return Result{}, microerror.Mask(err)
+	}
+	to, err := t.load(t.toDir, t.toRef)
+	if err != nil {
+		return Result{}, microerror.Mask(err)
+	}
+
+	r := Result{
+		FromVersion: from.version,
+		ToVersion:   to.version,
+		Changes:     []Change{},
+	}
+	if t.fromVersion != "" {
+		r.FromVersion = t.fromVersion
+	}
+	if t.toVersion != "" {
+		r.ToVersion = t.toVersion
+	}
+	r.Bump = bump(r.FromVersion, r.ToVersion)
+
+	r.Changes = append(r.Changes, diffValues(from.values, to.values)...)
+	r.Changes = append(r.Changes, diffSchemas(from.schema, to.schema)...)
+	for _, c := range r.Changes {
+		if c.Breaking {
+			r.Breaking = true
+		}
+	}
+
+	return r, nil
+}
+
+func (t *ValuesDiffTask) load(dir, ref string) (chart, error) {
+	if ref != "" {
+		return loadRef(dir, ref)
+	}
+	return loadDir(t.fs, dir)
+}
+
+// bump returns the kind of version bump from one version to the other. It is
+// unknown if either isn't a semantic version or the version decreased.
+func bump(from, to string) string {
+	f, err := semver.StrictNewVersion(from)
+	if err != nil {
+		return BumpUnknown
+	}
+	t, err := semver.StrictNewVersion(to)
+	if err != nil {
+		return BumpUnknown
+	}
+
+	switch {
+	case t.LessThan(f):
+		return BumpUnknown
+	case t.Major() != f.Major():
+		return BumpMajor
+	case t.Minor() != f.Minor() && f.Major() == 0:
+		return BumpMajor
+	case t.Minor() != f.Minor():
+		return BumpMinor
+	case t.Patch() != f.Patch():
+		return BumpPatch
+	default:
+		return BumpNone
+	}
+}
+
+func (t *ValuesDiffTask) String() string {
+	return fmt.Sprintf("%s:\t%s -> %s", "diff-helm-chart-values", side(t.fromDir, t.fromRef), side(t.toDir, t.toRef))
+}
+
+func side(dir, ref string) string {
+	if ref == "" {
+		return dir
+	}
+	return dir + "@" + ref
+}
+
+// NewValuesDiffTask creates a new ValuesDiffTask.
+func NewValuesDiffTask(config Config) (*ValuesDiffTask, error) {
+	if config.Fs == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Fs must not be empty", config)
+	}
+
+	if config.FromDir == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.FromDir must not be empty", config)
+	}
+
+	if config.ToDir == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ToDir must not be empty", config)
+	}
+
+	if config.FromDir == config.ToDir && config.FromRef == config.ToRef {
+		return nil, microerror.Maskf(invalidConfigError, "%T.FromDir and %T.FromRef must differ from %T.ToDir and %T.ToRef", config, config, config, config)
+	}
+
+	t := &ValuesDiffTask{
+		fs: config.Fs,
+
+		fromDir:     config.FromDir,
+		fromRef:     config.FromRef,
+		fromVersion: config.FromVersion,
+		toDir:       config.ToDir,
+		toRef:       config.ToRef,
+		toVersion:   config.ToVersion,
+	}
+
+	return t, nil
+}
@@ -0,0 +1,224 @@
+package helmvaluesdiff
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+// TestValuesDiffTask tests comparing chart directories.
+func TestValuesDiffTask(t *testing.T) {
+	testCases := []struct {
+		name            string
+		from            map[string]string
+		to              map[string]string
+		fromVersion     string
+		toVersion       string
+		expectedBump    string
+		expectedChanges int
+		expectedAllowed bool
+		errorMatcher    func(err error) bool
+	}{
+		{
+			name:            "case 0: compatible minor bump",
+			from:            map[string]string{"Chart.yaml": "version: 1.2.0\n", "values.yaml": "replicas: 1\n"},
+			to:              map[string]string{"Chart.yaml": "version: 1.3.0\n", "values.yaml": "replicas: 2\nextra: true\n"},
+			expectedBump:    BumpMinor,
+			expectedChanges: 2,
+			expectedAllowed: true,
+		},
+		{
+			name:            "case 1: breaking minor bump",
+			from:            map[string]string{"Chart.yaml": "version: 1.2.0\n", "values.yaml": "replicas: 1\n"},
+			to:              map[string]string{"Chart.yaml": "version: 1.3.0\n", "values.yaml": "{}\n"},
+			expectedBump:    BumpMinor,
+			expectedChanges: 1,
+		},
+		{
+			name:            "case 2: breaking major bump",
+			from:            map[string]string{"Chart.yaml": "version: 1.2.0\n", "values.yaml": "replicas: 1\n"},
+			to:              map[string]string{"Chart.yaml": "version: 2.0.0\n"},
+			expectedBump:    BumpMajor,
+			expectedChanges: 1,
+			expectedAllowed: true,
+		},
+		{
+			name:            "case 3: breaking 0.x minor bump",
+			from:            map[string]string{"Chart.yaml": "version: 0.2.0\n", "values.yaml": "replicas: 1\n", "values.schema.json": `{"properties": {"replicas": {"type": "integer"}}}`},
+			to:              map[string]string{"Chart.yaml": "version: 0.3.0\n", "values.yaml": "replicas: 1\n", "values.schema.json": `{"properties": {}}`},
+			expectedBump:    BumpMajor,
+			expectedChanges: 1,
+			expectedAllowed: true,
+		},
+		{
+			name:            "case 4: breaking change with placeholder version",
+			from:            map[string]string{"Chart.yaml": "version: 1.2.0\n", "values.yaml": "replicas: 1\n"},
+			to:              map[string]string{"Chart.yaml": "version: '[[ .Version ]]'\n", "values.yaml": "replicas: one\n"},
+			expectedBump:    BumpUnknown,
+			expectedChanges: 1,
+		},
+		{
+			name:            "case 5: breaking change with overridden version",
+			from:            map[string]string{"Chart.yaml": "version: 1.2.0\n", "values.yaml": "replicas: 1\n"},
+			to:              map[string]string{"Chart.yaml": "version: '[[ .Version ]]'\n", "values.yaml": "replicas: one\n"},
+			toVersion:       "2.0.0",
+			expectedBump:    BumpMajor,
+			expectedChanges: 1,
+			expectedAllowed: true,
+		},
+		{
+			name:            "case 6: unquoted placeholder versions",
+			from:            map[string]string{"Chart.yaml": "version: [[ .Version ]]\n", "values.yaml": "replicas: 1\n"},
+			to:              map[string]string{"Chart.yaml": "version: [[ .Version ]]\n", "values.yaml": "{}\n"},
+			expectedBump:    BumpUnknown,
+			expectedChanges: 1,
+		},
+		{
+			name:            "case 7: unquoted placeholder versions overridden",
+			from:            map[string]string{"Chart.yaml": "version: [[ .Version ]]\n", "values.yaml": "replicas: 1\n"},
+			to:              map[string]string{"Chart.yaml": "version: [[ .Version ]]\n", "values.yaml": "{}\n"},
+			fromVersion:     "1.0.0",
+			toVersion:       "2.0.0",
+			expectedBump:    BumpMajor,
+			expectedChanges: 1,
+			expectedAllowed: true,
+		},
+		{
+			name:         "case 8: missing Chart.yaml",
+			from:         map[string]string{"values.yaml": "replicas: 1\n"},
+			to:           map[string]string{"Chart.yaml": "version: 1.0.0\n"},
+			errorMatcher: IsInvalidChart,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			fs := afero.NewMemMapFs()
+			for dir, files := range map[string]map[string]string{"/from": tc.from, "/to": tc.to} {
+				for name, content := range files {
+					err := afero.WriteFile(fs, filepath.Join(dir, name), []byte(content), 0600)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			task, err := NewValuesDiffTask(Config{
+				Fs:          fs,
+				FromDir:     "/from",
+				FromVersion: tc.fromVersion,
+				ToDir:       "/to",
+				ToVersion:   tc.toVersion,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error when creating NewValuesDiffTask: %v\n", err)
+			}
+
+			result, err := task.Run()
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+			if err != nil {
+				return
+			}
+
+			if result.Bump != tc.expectedBump {
+				t.Errorf("bump == %#q, want %#q", result.Bump, tc.expectedBump)
+			}
+			if len(result.Changes) != tc.expectedChanges {
+				t.Errorf("changes == %v, want %d", result.Changes, tc.expectedChanges)
+			}
+			if result.Allowed() != tc.expectedAllowed {
+				t.Errorf("allowed == %t, want %t", result.Allowed(), tc.expectedAllowed)
+			}
+		})
+	}
+}
+
+// TestValuesDiffTaskGitRef tests comparing a chart at a git ref with the
+// working tree.
+func TestValuesDiffTaskGitRef(t *testing.T) {
+	repoDir := t.TempDir()
+	chartDir := filepath.Join(repoDir, "helm", "my-app")
+
+	repo, err := git.PlainInit(repoDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := afero.NewOsFs()
+	err = fs.MkdirAll(chartDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) {
+		err := afero.WriteFile(fs, filepath.Join(chartDir, name), []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("Chart.yaml", "version: 1.0.0\n")
+	write("values.yaml", "image:\n  tag: 1.0.0\n")
+	_, err = wt.Add(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = wt.Commit("initial", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Unix(0, 0)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write("Chart.yaml", "version: 1.1.0\n")
+	write("values.yaml", "image:\n  tag: 1.1.0\n  registry: gsoci.azurecr.io\n")
+
+	task, err := NewValuesDiffTask(Config{
+		Fs:      fs,
+		FromDir: chartDir,
+		FromRef: "HEAD",
+		ToDir:   chartDir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := task.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Result{
+		FromVersion: "1.0.0",
+		ToVersion:   "1.1.0",
+		Bump:        BumpMinor,
+		Changes: []Change{
+			{File: "values.yaml", Path: "image.registry", Type: ChangeAdded, NewType: "string", New: "gsoci.azurecr.io"},
+			{File: "values.yaml", Path: "image.tag", Type: ChangeDefaultChanged, Old: "1.0.0", New: "1.1.0"},
+		},
+	}
+	if diff := cmp.Diff(expected, result); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,303 @@
+// Package helmverify validates the resources rendered from a helm chart
+// against Kubernetes and CRD JSON schemas from local directories.
+package helmverify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/spf13/afero"
+
+	"github.com/giantswarm/architect/v2/helmrender"
+	"github.com/giantswarm/architect/v2/schemacheck"
+)
+
+// Statuses of a verified resource.
+const (
+	StatusValid    = "valid"
+	StatusInvalid  = "invalid"
+	StatusNoSchema = "no-schema"
+)
+
+// VerifyChartTask is used to run a verify-helm-chart command.
+type VerifyChartTask struct {
+	fs       afero.Fs
+	renderer *helmrender.Renderer
+
+	chartDir             string
+	schemaDirs           []string
+	ignoreMissingSchemas bool
+
+	// schemas caches compiled schemas by path.
+	schemas map[string]*jsonschema.Schema
+}
+
+// Config holds configuration for building a new VerifyChartTask.
+type Config struct {
+	Fs afero.Fs
+
+	// ChartDir is the directory of the (templated) chart to verify.
+	ChartDir string
+	// SchemaDirs are the directories searched for JSON schemas, in order.
+	// See SchemaCandidates for the supported layouts.
+	SchemaDirs []string
+	// IgnoreMissingSchemas makes resources without a schema pass
+	// verification.
+	IgnoreMissingSchemas bool
+
+	// ReleaseName, Namespace and KubeVersion configure rendering, see
+	// helmrender.Config.
+	ReleaseName string
+	Namespace   string
+	KubeVersion string
+}
+
+// Report is the outcome of verifying the chart rendered with one values file.
+type Report struct {
+	// ValuesFile is the values file, relative to the chart directory.
+	ValuesFile string `json:"valuesFile"`
+	// Failed is set if the chart couldn't be rendered, a resource is invalid
+	// or, unless missing schemas are ignored, has no schema.
+	Failed bool `json:"failed"`
+	// Error is set if the chart couldn't be rendered.
+	Error     string           `json:"error,omitempty"`
+	Resources []ResourceReport `json:"resources"`
+}
+
+// ResourceReport is the outcome of verifying a single rendered resource.
+type ResourceReport struct {
+	Template   string `json:"template"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	// Schema is the path of the schema the resource was validated against.
+	Schema string `json:"schema,omitempty"`
+	// Violations are the schema violations, each prefixed with the JSON
+	// pointer of the offending value.
+	Violations []string `json:"violations,omitempty"`
+}
+
+// Run renders the chart with values.yaml and every ci/*.yaml values file and
+// validates every resource against its schema. A values file the chart fails
+// to render with is reported without stopping verification. The returned
+// error is only set for failures unrelated to the chart.
+func (t *VerifyChartTask) Run() ([]Report, error) {
+	valuesFiles, err := t.renderer.ValuesFiles()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	reports := make([]Report, 0, len(valuesFiles))
+	for _, valuesFile := range valuesFiles {
+		report := Report{
+			ValuesFile: valuesFile,
+			Resources:  []ResourceReport{},
+		}
+
+		resources, err := t.renderer.Render(valuesFile)
+		if helmrender.IsRenderFailed(err) {
+			report.Failed = true
+			report.Error = err.Error()
+			reports = append(reports, report)
+			continue
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, r := range resources {
+			rr, err := t.verify(r)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			report.Resources = append(report.Resources, rr)
+
+			if rr.Status == StatusInvalid || (rr.Status == StatusNoSchema && !t.ignoreMissingSchemas) {
+				report.Failed = true
+			}
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+func (t *VerifyChartTask) verify(r helmrender.Resource) (ResourceReport, error) {
+	rr := ResourceReport{
+		Template:   r.Template,
+		APIVersion: r.APIVersion,
+		Kind:       r.Kind,
+		Name:       r.Name,
+	}
+
+	if r.APIVersion == "" || r.Kind == "" {
+		rr.Status = StatusInvalid
+		rr.Violations = []string{"resource must set apiVersion and kind"}
+		return rr, nil
+	}
+
+	schemaPath, err := t.findSchema(r.APIVersion, r.Kind)
+	if err != nil {
+		return ResourceReport{}, microerror.Mask(err)
+	}
+	if schemaPath == "" {
+		rr.Status = StatusNoSchema
+		return rr, nil
+	}
+	rr.Schema = schemaPath
+
+	schema, err := t.compile(schemaPath)
+	if err != nil {
+		return ResourceReport{}, microerror.Mask(err)
+	}
+
+	// Decode the object the way the validator expects JSON numbers.
+	var instance interface{}
+	{
+		data, err := json.Marshal(r.Object)
+		if err != nil {
+			return ResourceReport{}, microerror.Mask(err)
+		}
+		instance, err = jsonschema.UnmarshalJSON(bytes.NewReader(data))
+		if err != nil {
+			return ResourceReport{}, microerror.Mask(err)
+		}
+	}
+
+	err = schema.Validate(instance)
+	if err == nil {
+		rr.Status = StatusValid
+		return rr, nil
+	}
+
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return ResourceReport{}, microerror.Mask(err)
+	}
+
+	rr.Status = StatusInvalid
+	for _, v := range schemacheck.Violations(validationErr) {
+		rr.Violations = append(rr.Violations, v.String())
+	}
+
+	return rr, nil
+}
+
+// SchemaCandidates returns the paths, relative to a schema directory, where
+// the schema of the given resource type is looked up, in order:
+//
+//   - <kind>-<group prefix>-<version>.json, or <kind>-<version>.json for the
+//     core group, as in https://github.com/yannh/kubernetes-json-schema, e.g.
+//     `deployment-apps-v1.json`.
+//   - <group>/<kind>_<version>.json as in
+//     https://github.com/datreeio/CRDs-catalog, e.g.
+//     `monitoring.coreos.com/servicemonitor_v1.json`.
+//
+// The kind is lower case.
+func SchemaCandidates(apiVersion, kind string) []string {
+	kind = strings.ToLower(kind)
+	group, version, found := strings.Cut(apiVersion, "/")
+	if !found {
+		return []string{fmt.Sprintf("%s-%s.json", kind, apiVersion)}
+	}
+
+	prefix, _, _ := strings.Cut(group, ".")
+
+	return []string{
+		fmt.Sprintf("%s-%s-%s.json", kind, prefix, version),
+		filepath.Join(group, fmt.Sprintf("%s_%s.json", kind, version)),
+	}
+}
+
+// findSchema returns the path of the first schema found for the given
+// resource type, or an empty string if there is none.
+func (t *VerifyChartTask) findSchema(apiVersion, kind string) (string, error) {
+	for _, dir := range t.schemaDirs {
+		for _, candidate := range SchemaCandidates(apiVersion, kind) {
+			p := filepath.Join(dir, candidate)
+			exists, err := afero.Exists(t.fs, p)
+			if err != nil {
+				return "", microerror.Mask(err)
+			}
+			if exists {
+				return p, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+func (t *VerifyChartTask) compile(schemaPath string) (*jsonschema.Schema, error) {
+	if s, ok := t.schemas[schemaPath]; ok {
+		return s, nil
+	}
+
+	url, err := schemacheck.FileURL(schemaPath)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	c := jsonschema.NewCompiler()
+	c.DefaultDraft(jsonschema.Draft7)
+	c.UseLoader(jsonschema.SchemeURLLoader{
+		"file": schemacheck.Loader{Fs: t.fs},
+	})
+
+	s, err := c.Compile(url)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "invalid schema %#q: %s", schemaPath, err)
+	}
+	t.schemas[schemaPath] = s
+
+	return s, nil
+}
+
+func (t *VerifyChartTask) String() string {
+	return fmt.Sprintf("%s:\t%s schema-dirs:%s", "verify-helm-chart", t.chartDir, strings.Join(t.schemaDirs, ","))
+}
+
+// NewVerifyChartTask creates a new VerifyChartTask.
+func NewVerifyChartTask(config Config) (*VerifyChartTask, error) {
+	if config.Fs == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Fs must not be empty", config)
+	}
+
+	if config.ChartDir == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ChartDir must not be empty", config)
+	}
+
+	if len(config.SchemaDirs) == 0 && !config.IgnoreMissingSchemas {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SchemaDirs must not be empty unless %T.IgnoreMissingSchemas is set", config, config)
+	}
+
+	renderer, err := helmrender.NewRenderer(helmrender.Config{
+		Fs:          config.Fs,
+		ChartDir:    config.ChartDir,
+		ReleaseName: config.ReleaseName,
+		Namespace:   config.Namespace,
+		KubeVersion: config.KubeVersion,
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	t := &VerifyChartTask{
+		fs:       config.Fs,
+		renderer: renderer,
+
+		chartDir:             config.ChartDir,
+		schemaDirs:           config.SchemaDirs,
+		ignoreMissingSchemas: config.IgnoreMissingSchemas,
+
+		schemas: map[string]*jsonschema.Schema{},
+	}
+
+	return t, nil
+}
@@ -0,0 +1,217 @@
+package helmverify
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+const deploymentSchema = `{
+  "$schema": "http://json-schema.org/schema#",
+  "type": "object",
+  "properties": {
+    "spec": {
+      "type": "object",
+      "properties": {
+        "replicas": {"type": "integer"}
+      }
+    }
+  }
+}`
+
+const chartFiles = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+spec:
+  replicas: {{ .Values.replicas }}
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: my-widget
+`
+
+// TestVerifyChartTask tests verifying the resources rendered with every values
+// file.
+func TestVerifyChartTask(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		files                map[string]string
+		ignoreMissingSchemas bool
+		expectedReports      []Report
+	}{
+		{
+			name: "case 0: valid and invalid values files",
+			files: map[string]string{
+				"chart/ci/bad-values.yaml":                 "replicas: three\n",
+				"schemas/deployment-apps-v1.json":          deploymentSchema,
+				"schemas/example.com/widget_v1.json":       `{"type": "object"}`,
+				"chart/templates/resources.yaml":           chartFiles,
+				"chart/values.yaml":                        "replicas: 1\n",
+				"chart/Chart.yaml":                         "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+				"schemas/unrelated/configmap_v1beta1.json": `{}`,
+			},
+			expectedReports: []Report{
+				{
+					ValuesFile: "values.yaml",
+					Resources: []ResourceReport{
+						{Template: "my-app/templates/resources.yaml", APIVersion: "apps/v1", Kind: "Deployment", Name: "my-app", Status: StatusValid, Schema: "/schemas/deployment-apps-v1.json"},
+						{Template: "my-app/templates/resources.yaml", APIVersion: "example.com/v1", Kind: "Widget", Name: "my-widget", Status: StatusValid, Schema: "/schemas/example.com/widget_v1.json"},
+					},
+				},
+				{
+					ValuesFile: "ci/bad-values.yaml",
+					Failed:     true,
+					Resources: []ResourceReport{
+						{Template: "my-app/templates/resources.yaml", APIVersion: "apps/v1", Kind: "Deployment", Name: "my-app", Status: StatusInvalid, Schema: "/schemas/deployment-apps-v1.json", Violations: []string{"/spec/replicas: got string, want integer"}},
+						{Template: "my-app/templates/resources.yaml", APIVersion: "example.com/v1", Kind: "Widget", Name: "my-widget", Status: StatusValid, Schema: "/schemas/example.com/widget_v1.json"},
+					},
+				},
+			},
+		},
+		{
+			name: "case 1: missing schema fails",
+			files: map[string]string{
+				"schemas/deployment-apps-v1.json": deploymentSchema,
+				"chart/templates/resources.yaml":  chartFiles,
+				"chart/values.yaml":               "replicas: 1\n",
+				"chart/Chart.yaml":                "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+			},
+			expectedReports: []Report{
+				{
+					ValuesFile: "values.yaml",
+					Failed:     true,
+					Resources: []ResourceReport{
+						{Template: "my-app/templates/resources.yaml", APIVersion: "apps/v1", Kind: "Deployment", Name: "my-app", Status: StatusValid, Schema: "/schemas/deployment-apps-v1.json"},
+						{Template: "my-app/templates/resources.yaml", APIVersion: "example.com/v1", Kind: "Widget", Name: "my-widget", Status: StatusNoSchema},
+					},
+				},
+			},
+		},
+		{
+			name: "case 2: missing schema ignored",
+			files: map[string]string{
+				"schemas/deployment-apps-v1.json": deploymentSchema,
+				"chart/templates/resources.yaml":  chartFiles,
+				"chart/values.yaml":               "replicas: 1\n",
+				"chart/Chart.yaml":                "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+			},
+			ignoreMissingSchemas: true,
+			expectedReports: []Report{
+				{
+					ValuesFile: "values.yaml",
+					Resources: []ResourceReport{
+						{Template: "my-app/templates/resources.yaml", APIVersion: "apps/v1", Kind: "Deployment", Name: "my-app", Status: StatusValid, Schema: "/schemas/deployment-apps-v1.json"},
+						{Template: "my-app/templates/resources.yaml", APIVersion: "example.com/v1", Kind: "Widget", Name: "my-widget", Status: StatusNoSchema},
+					},
+				},
+			},
+		},
+		{
+			name: "case 3: values file failing to render",
+			files: map[string]string{
+				"chart/ci/broken-values.yaml":    "name: \"\"\n",
+				"chart/templates/resources.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ required \"name is required\" .Values.name }}\n",
+				"chart/values.yaml":              "name: my-app\n",
+				"chart/Chart.yaml":               "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+			},
+			ignoreMissingSchemas: true,
+			expectedReports: []Report{
+				{
+					ValuesFile: "values.yaml",
+					Resources: []ResourceReport{
+						{Template: "my-app/templates/resources.yaml", APIVersion: "v1", Kind: "ConfigMap", Name: "my-app", Status: StatusNoSchema},
+					},
+				},
+				{
+					ValuesFile: "ci/broken-values.yaml",
+					Failed:     true,
+					Resources:  []ResourceReport{},
+				},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			fs := afero.NewMemMapFs()
+			for name, content := range tc.files {
+				err := afero.WriteFile(fs, filepath.Join("/", name), []byte(content), 0600)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			task, err := NewVerifyChartTask(Config{
+				Fs:                   fs,
+				ChartDir:             "/chart",
+				SchemaDirs:           []string{"/schemas"},
+				IgnoreMissingSchemas: tc.ignoreMissingSchemas,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error when creating NewVerifyChartTask: %v\n", err)
+			}
+
+			reports, err := task.Run()
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+
+			// Render errors come from helm; only check they are set.
+			for i := range reports {
+				if reports[i].Error != "" {
+					reports[i].Error = ""
+				} else if reports[i].Failed && len(reports[i].Resources) == 0 {
+					t.Errorf("report %#q failed without error", reports[i].ValuesFile)
+				}
+			}
+
+			if diff := cmp.Diff(tc.expectedReports, reports); diff != "" {
+				t.Errorf("reports mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestSchemaCandidates tests the schema lookup paths of resource types.
+func TestSchemaCandidates(t *testing.T) {
+	testCases := []struct {
+		name       string
+		apiVersion string
+		kind       string
+		expected   []string
+	}{
+		{
+			name:       "case 0: core group",
+			apiVersion: "v1",
+			kind:       "ConfigMap",
+			expected:   []string{"configmap-v1.json"},
+		},
+		{
+			name:       "case 1: named group",
+			apiVersion: "monitoring.coreos.com/v1",
+			kind:       "ServiceMonitor",
+			expected: []string{
+				"servicemonitor-monitoring-v1.json",
+				"monitoring.coreos.com/servicemonitor_v1.json",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			got := SchemaCandidates(tc.apiVersion, tc.kind)
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("candidates mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Package schemacheck holds the helpers shared by the commands validating
+// documents against JSON schemas offline.
+package schemacheck
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/spf13/afero"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// genericSchemaDraft is the `$schema` of the Kubernetes JSON schemas, which
+// doesn't name a draft the validator knows.
+const genericSchemaDraft = "http://json-schema.org/schema#"
+
+// Violation is a leaf error of a failed validation.
+type Violation struct {
+	// Pointer is the RFC 6901 JSON pointer of the invalid value, empty for
+	// the whole document.
+	Pointer string
+	Message string
+}
+
+// String returns the violation as `<pointer>: <message>`, or just the
+// message for the whole document.
+func (v Violation) String() string {
+	if v.Pointer == "" {
+		return v.Message
+	}
+	return v.Pointer + ": " + v.Message
+}
+
+// Violations flattens err into its leaf violations, sorted by pointer and
+// message.
+func Violations(err *jsonschema.ValidationError) []Violation {
+	p := message.NewPrinter(language.English)
+
+	var violations []Violation
+	var collect func(e *jsonschema.ValidationError)
+	collect = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			violations = append(violations, Violation{
+				Pointer: Pointer(e.InstanceLocation),
+				Message: e.ErrorKind.LocalizedString(p),
+			})
+			return
+		}
+		for _, c := range e.Causes {
+			collect(c)
+		}
+	}
+	collect(err)
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Pointer != violations[j].Pointer {
+			return violations[i].Pointer < violations[j].Pointer
+		}
+		return violations[i].Message < violations[j].Message
+	})
+
+	return violations
+}
+
+// Pointer formats tokens as an RFC 6901 JSON pointer. The pointer of the
+// whole document is empty.
+func Pointer(tokens []string) string {
+	var b strings.Builder
+	for _, tok := range tokens {
+		tok = strings.ReplaceAll(tok, "~", "~0")
+		tok = strings.ReplaceAll(tok, "/", "~1")
+		b.WriteString("/" + tok)
+	}
+	return b.String()
+}
+
+// Loader loads `file://` schema URLs from an afero filesystem. The generic
+// `$schema` of the Kubernetes schemas, which isn't a draft the validator
+// knows, is dropped so the default draft of the compiler applies.
+type Loader struct {
+	Fs afero.Fs
+}
+
+// Load implements jsonschema.URLLoader.
+func (l Loader) Load(url string) (any, error) {
+	p, err := jsonschema.FileLoader{}.ToFile(url)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	f, err := l.Fs.Open(p)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	defer f.Close()
+
+	doc, err := jsonschema.UnmarshalJSON(f)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if m, ok := doc.(map[string]any); ok && m["$schema"] == genericSchemaDraft {
+		delete(m, "$schema")
+	}
+
+	return doc, nil
+}
+
+// FileURL returns the `file://` URL of the path p.
+func FileURL(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	abs = filepath.ToSlash(abs)
+	if !strings.HasPrefix(abs, "/") {
+		abs = "/" + abs
+	}
+
+	return "file://" + abs, nil
+}
@@ -0,0 +1,66 @@
+package schemacheck
+
+import (
+	"testing"
+)
+
+func TestPointer(t *testing.T) {
+	testCases := []struct {
+		name           string
+		tokens         []string
+		expectedResult string
+	}{
+		{
+			name:           "case 0: whole document",
+			tokens:         nil,
+			expectedResult: "",
+		},
+		{
+			name:           "case 1: nested value",
+			tokens:         []string{"spec", "replicas"},
+			expectedResult: "/spec/replicas",
+		},
+		{
+			name:           "case 2: escaped tokens",
+			tokens:         []string{"annotations", "application.giantswarm.io/team", "a~b"},
+			expectedResult: "/annotations/application.giantswarm.io~1team/a~0b",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Log(tc.name)
+
+		result := Pointer(tc.tokens)
+		if result != tc.expectedResult {
+			t.Fatalf("expected %q, got %q", tc.expectedResult, result)
+		}
+	}
+}
+
+func TestViolationString(t *testing.T) {
+	testCases := []struct {
+		name           string
+		violation      Violation
+		expectedResult string
+	}{
+		{
+			name:           "case 0: whole document",
+			violation:      Violation{Message: "missing property 'spec'"},
+			expectedResult: "missing property 'spec'",
+		},
+		{
+			name:           "case 1: nested value",
+			violation:      Violation{Pointer: "/spec/replicas", Message: "got string, want integer"},
+			expectedResult: "/spec/replicas: got string, want integer",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Log(tc.name)
+
+		result := tc.violation.String()
+		if result != tc.expectedResult {
+			t.Fatalf("expected %q, got %q", tc.expectedResult, result)
+		}
+	}
+}